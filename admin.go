@@ -0,0 +1,249 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openfaas/connector-sdk/types"
+	crontypes "github.com/openfaas/cron-connector/types"
+)
+
+// runningFunctions holds the functions which are scheduled, so that they
+// can be read outside of the loop in startFunctionProbe
+type runningFunctions struct {
+	mu        sync.RWMutex
+	functions crontypes.ScheduledFunctions
+}
+
+// List returns a copy of the scheduled functions
+func (r *runningFunctions) List() crontypes.ScheduledFunctions {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.functions)
+}
+
+// Set replaces the scheduled functions
+func (r *runningFunctions) Set(functions crontypes.ScheduledFunctions) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.functions = functions
+}
+
+// adminAPI serves the endpoints used to inspect and operate the connector
+type adminAPI struct {
+	scheduler *crontypes.Scheduler
+	running   *runningFunctions
+	invoker   *types.Invoker
+
+	// history is served to clients with the admin token, as it holds
+	// the start of each response body, when nil it is not served
+	history *crontypes.RunHistory
+
+	// tokenFile holds the bearer token for the endpoints which change
+	// anything, when empty those endpoints are not served
+	tokenFile string
+
+	// leaderElection is set when a standby takes over from the leader
+	leaderElection bool
+
+	// runLock is set when every replica runs the schedules
+	runLock bool
+
+	// sharder is set when functions are split between replicas, each
+	// replica only serves the functions it schedules
+	sharder *crontypes.Sharder
+}
+
+func (a *adminAPI) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /schedules", a.listSchedules)
+
+	if len(a.tokenFile) > 0 {
+		if a.history != nil {
+			mux.HandleFunc("GET /runs", a.authorize(listRuns(a.history)))
+		}
+
+		mux.HandleFunc("POST /schedules/{namespace}/{name}/run", a.authorize(a.runNow))
+		mux.HandleFunc("POST /schedules/{namespace}/{name}/pause", a.authorize(a.setPaused(true)))
+		mux.HandleFunc("POST /schedules/{namespace}/{name}/resume", a.authorize(a.setPaused(false)))
+	}
+}
+
+// authorize only calls next for requests which carry the admin token
+// as a bearer token, the token is read for each request so that it
+// can be rotated
+func (a *adminAPI) authorize(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := os.ReadFile(a.tokenFile)
+		if err != nil {
+			log.Printf("Error reading admin token: %s", err)
+			http.Error(w, "unable to read admin token", http.StatusInternalServerError)
+			return
+		}
+
+		want := strings.TrimSpace(string(data))
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || len(want) == 0 || subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// find returns the scheduled function with the namespace and name given in
+// the path of the request. The schedule query parameter picks one of the
+// expressions of a function with several, otherwise the first is used.
+func (a *adminAPI) find(r *http.Request) (crontypes.ScheduledFunction, bool) {
+	namespace, name := r.PathValue("namespace"), r.PathValue("name")
+	schedule := r.URL.Query().Get("schedule")
+
+	for _, function := range a.running.List() {
+		c := function.Function
+		if c.Namespace == namespace && c.Name == name && (len(schedule) == 0 || c.Schedule == schedule) {
+			return function, true
+		}
+	}
+
+	return crontypes.ScheduledFunction{}, false
+}
+
+// notFound responds to a request for a function which is not scheduled
+// here, naming the replica which schedules it when functions are sharded
+func (a *adminAPI) notFound(w http.ResponseWriter, r *http.Request) {
+	if a.sharder != nil {
+		owner := a.sharder.Owner(r.PathValue("namespace"), r.PathValue("name"))
+		if len(owner) > 0 && owner != a.sharder.Identity() {
+			http.Error(w, fmt.Sprintf("function not found, it is scheduled by replica: %s", owner), http.StatusNotFound)
+			return
+		}
+	}
+
+	http.Error(w, "function not found", http.StatusNotFound)
+}
+
+// runNow invokes a scheduled function straight away, in the same way as
+// when its schedule fires
+func (a *adminAPI) runNow(w http.ResponseWriter, r *http.Request) {
+	function, ok := a.find(r)
+	if !ok {
+		a.notFound(w, r)
+		return
+	}
+
+	log.Printf("Manual run requested: %s [%s] from %s", function.Function.String(), function.Function.ScheduleString(), r.RemoteAddr)
+
+	run, err := a.scheduler.Trigger(function.Function, a.invoker)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, crontypes.ErrRunSkipped) {
+			status = http.StatusConflict
+		} else if errors.Is(err, crontypes.ErrShuttingDown) {
+			status = http.StatusServiceUnavailable
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, struct {
+		RunID     string    `json:"run_id"`
+		Name      string    `json:"name"`
+		Namespace string    `json:"namespace"`
+		Schedule  string    `json:"schedule"`
+		Scheduled time.Time `json:"scheduled"`
+	}{
+		RunID:     run.ID,
+		Name:      run.Function,
+		Namespace: run.Namespace,
+		Schedule:  function.Function.Schedule,
+		Scheduled: run.Scheduled,
+	})
+}
+
+// listSchedules returns each scheduled function and when it runs, the
+// namespace query parameter limits the list to a single namespace
+func (a *adminAPI) listSchedules(w http.ResponseWriter, r *http.Request) {
+	namespace := r.URL.Query().Get("namespace")
+
+	schedules := []crontypes.ScheduleStatus{}
+	for _, function := range a.running.List() {
+		if len(namespace) > 0 && function.Function.Namespace != namespace {
+			continue
+		}
+		schedules = append(schedules, a.scheduler.Status(function))
+	}
+
+	slices.SortFunc(schedules, func(a, b crontypes.ScheduleStatus) int {
+		if c := strings.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.Name, b.Name); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Schedule, b.Schedule)
+	})
+
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+// pauseUnsupported returns why functions cannot be paused at runtime, or an
+// empty string if they can. Pause state is kept by each replica, so it
+// would be ignored or lost when another replica runs the function.
+func (a *adminAPI) pauseUnsupported() string {
+	switch {
+	case a.runLock:
+		return "run_lock, as every replica runs them"
+	case a.leaderElection:
+		return "leader_election, as a standby which takes over would run them"
+	case a.sharder != nil:
+		return "shard_membership, as a replica which takes them over would run them"
+	}
+
+	return ""
+}
+
+// setPaused returns a handler which pauses or resumes each schedule
+// of a function
+func (a *adminAPI) setPaused(paused bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if reason := a.pauseUnsupported(); len(reason) > 0 {
+			http.Error(w, fmt.Sprintf("functions cannot be paused with %s, use the suspend annotation", reason), http.StatusConflict)
+			return
+		}
+
+		function, ok := a.find(r)
+		if !ok {
+			a.notFound(w, r)
+			return
+		}
+
+		c := function.Function
+		setPaused, action := a.scheduler.Resume, "Resumed"
+		if paused {
+			setPaused, action = a.scheduler.Pause, "Paused"
+		}
+
+		if err := setPaused(c.Namespace, c.Name); err != nil {
+			log.Printf("Error pausing or resuming %s: %s", c.String(), err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		log.Printf("%s: %s from %s", action, c.String(), r.RemoteAddr)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -2,10 +2,15 @@ package main
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/openfaas/connector-sdk/types"
+	crontypes "github.com/openfaas/cron-connector/types"
 )
 
 func getControllerConfig() (*types.ControllerConfig, error) {
@@ -50,3 +55,327 @@ func getControllerConfig() (*types.ControllerConfig, error) {
 		PrintRequestBody:        false,
 	}, nil
 }
+
+// getDefaults reads the connector-wide defaults for cron functions
+func getDefaults() (crontypes.Defaults, error) {
+	scheduleFormat, err := crontypes.ParseScheduleFormat(os.Getenv("schedule_format"))
+	if err != nil {
+		return crontypes.Defaults{}, err
+	}
+
+	headerSecretsPath, err := getHeaderSecretsPath()
+	if err != nil {
+		return crontypes.Defaults{}, err
+	}
+
+	var timeout time.Duration
+	if val, exists := os.LookupEnv("invocation_timeout"); exists && len(val) > 0 {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return crontypes.Defaults{}, err
+		}
+		timeout = d
+	}
+
+	var jitter time.Duration
+	if val, exists := os.LookupEnv("jitter"); exists && len(val) > 0 {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return crontypes.Defaults{}, err
+		}
+		jitter = d
+	}
+
+	jitterMode, err := crontypes.ParseJitterMode(os.Getenv("jitter_mode"))
+	if err != nil {
+		return crontypes.Defaults{}, err
+	}
+
+	return crontypes.Defaults{
+		ScheduleFormat:    scheduleFormat,
+		HeaderSecretsPath: headerSecretsPath,
+		Timeout:           timeout,
+		Jitter:            jitter,
+		JitterMode:        jitterMode,
+	}, nil
+}
+
+// getHeaderSecretsPath returns the directory the secret_headers annotation
+// reads from, which has no default. It must not overlap the directory of
+// the connector's own credentials, or hold the admin token, as any function
+// could then send them to itself.
+func getHeaderSecretsPath() (string, error) {
+	dir := os.Getenv("header_secrets_path")
+	if len(dir) == 0 {
+		return "", nil
+	}
+
+	private := []string{"/var/openfaas/secrets"}
+	if v, exists := os.LookupEnv("secret_mount_path"); exists && len(v) > 0 {
+		private = append(private, v)
+	}
+	if v := getAdminTokenFile(); len(v) > 0 {
+		private = append(private, v)
+	}
+
+	for _, p := range private {
+		if pathsOverlap(dir, p) {
+			return "", fmt.Errorf("header_secrets_path must not overlap %s, which holds the connector's credentials", p)
+		}
+	}
+
+	return dir, nil
+}
+
+// pathsOverlap returns true if a and b are the same path, or one is
+// inside the other
+func pathsOverlap(a, b string) bool {
+	a, b = filepath.Clean(a), filepath.Clean(b)
+	inside := func(parent, child string) bool {
+		rel, err := filepath.Rel(parent, child)
+		return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	}
+
+	return inside(a, b) || inside(b, a)
+}
+
+// getStateDir returns the directory the connector keeps its state in,
+// which should be on a volume for the state to survive a restart
+func getStateDir() string {
+	if val, exists := os.LookupEnv("state_dir"); exists && len(val) > 0 {
+		return val
+	}
+
+	return filepath.Join(os.TempDir(), "cron-connector")
+}
+
+// getHTTPPort returns the port the connector's HTTP server listens on
+func getHTTPPort() (int, error) {
+	port := 8081
+	if val, exists := os.LookupEnv("http_port"); exists && len(val) > 0 {
+		p, err := strconv.Atoi(val)
+		if err != nil || p < 1 || p > 65535 {
+			return 0, fmt.Errorf("invalid http_port: %q", val)
+		}
+		port = p
+	}
+
+	return port, nil
+}
+
+// getAdminAPIEnabled returns true if the admin API should be served
+func getAdminAPIEnabled() bool {
+	val := os.Getenv("admin_api")
+	return val == "1" || val == "true"
+}
+
+// getAdminTokenFile returns the path of the file which holds the bearer
+// token for the admin API, when empty the endpoints which need it are
+// not served
+func getAdminTokenFile() string {
+	return os.Getenv("admin_token_file")
+}
+
+// getShutdownGracePeriod returns how long runs in flight are waited for
+// when the connector is stopped, which should be shorter than the time
+// given by the orchestrator, i.e. terminationGracePeriodSeconds
+func getShutdownGracePeriod() (time.Duration, error) {
+	gracePeriod := time.Second * 25
+	if val, exists := os.LookupEnv("shutdown_grace_period"); exists && len(val) > 0 {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, err
+		}
+		gracePeriod = d
+	}
+
+	return gracePeriod, nil
+}
+
+// getLivenessThreshold returns how long the reconcile loop or the
+// scheduler can stall before the connector is no longer live
+func getLivenessThreshold(rebuildInterval time.Duration) (time.Duration, error) {
+	threshold := time.Minute * 5
+	if rebuildInterval*3 > threshold {
+		threshold = rebuildInterval * 3
+	}
+
+	if val, exists := os.LookupEnv("liveness_threshold"); exists && len(val) > 0 {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return 0, err
+		}
+		if d <= rebuildInterval {
+			return 0, fmt.Errorf("liveness_threshold must be longer than rebuild_interval: %s", val)
+		}
+		threshold = d
+	}
+
+	return threshold, nil
+}
+
+// getHistoryConfig reads the limits of the run history, and how much
+// of each response body it keeps
+func getHistoryConfig() (crontypes.HistoryLimits, int, error) {
+	limits := crontypes.HistoryLimits{
+		MaxRuns:   1000,
+		Retention: time.Hour * 24 * 7,
+	}
+
+	if val, exists := os.LookupEnv("history_max_runs"); exists && len(val) > 0 {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 1 {
+			return crontypes.HistoryLimits{}, 0, fmt.Errorf("invalid history_max_runs: %q", val)
+		}
+		limits.MaxRuns = n
+	}
+
+	if val, exists := os.LookupEnv("history_retention"); exists && len(val) > 0 {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return crontypes.HistoryLimits{}, 0, err
+		}
+		limits.Retention = d
+	}
+
+	bodyLimit := 1024
+	if val, exists := os.LookupEnv("history_body_limit"); exists && len(val) > 0 {
+		n, err := strconv.Atoi(val)
+		if err != nil || n < 0 {
+			return crontypes.HistoryLimits{}, 0, fmt.Errorf("invalid history_body_limit: %q", val)
+		}
+		bodyLimit = n
+	}
+
+	return limits, bodyLimit, nil
+}
+
+// getLeaderElection returns the leader election set by the leader_election
+// environment variable, either "file" or "kubernetes", or nil when only a
+// single replica is run
+func getLeaderElection(stateDir string) (*crontypes.LeaderElection, error) {
+	backend := os.Getenv("leader_election")
+	if len(backend) == 0 || backend == "false" {
+		return nil, nil
+	}
+
+	duration := time.Second * 15
+	if val, exists := os.LookupEnv("leader_election_duration"); exists && len(val) > 0 {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, err
+		}
+		if d < time.Second*3 {
+			return nil, fmt.Errorf("leader_election_duration must be at least 3s: %s", val)
+		}
+		duration = d
+	}
+
+	identity := os.Getenv("leader_election_identity")
+	if len(identity) == 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, err
+		}
+		identity = hostname
+	}
+
+	lease := os.Getenv("leader_election_lease")
+
+	switch backend {
+	case "file":
+		if len(lease) == 0 {
+			lease = filepath.Join(stateDir, "leader.lease")
+		}
+		return crontypes.NewLeaderElection(crontypes.NewFileLease(lease), identity, duration), nil
+	case "kubernetes":
+		if len(lease) == 0 {
+			lease = "cron-connector"
+		}
+		k8sLease, err := crontypes.NewKubernetesLease(os.Getenv("leader_election_namespace"), lease)
+		if err != nil {
+			return nil, err
+		}
+		return crontypes.NewLeaderElection(k8sLease, identity, duration), nil
+	}
+
+	return nil, fmt.Errorf("unknown leader_election: %q, use \"file\" or \"kubernetes\"", backend)
+}
+
+// getRunLock returns the lock set by the run_lock environment variable,
+// either "file" or "memory", or nil when runs are not claimed
+func getRunLock(stateDir string) (crontypes.RunLock, error) {
+	switch os.Getenv("run_lock") {
+	case "", "false":
+		return nil, nil
+	case "file":
+		dir := os.Getenv("run_lock_dir")
+		if len(dir) == 0 {
+			dir = filepath.Join(stateDir, "run_locks")
+		}
+		return crontypes.NewFileRunLock(dir), nil
+	}
+
+	return nil, fmt.Errorf("unknown run_lock: %q, use \"file\"", os.Getenv("run_lock"))
+}
+
+// getSharder returns the Sharder which splits functions between replicas,
+// or nil when sharding is not enabled, along with how often the members
+// are refreshed
+func getSharder(stateDir string) (*crontypes.Sharder, time.Duration, error) {
+	backend := os.Getenv("shard_membership")
+	if len(backend) == 0 || backend == "false" {
+		return nil, 0, nil
+	}
+
+	interval := time.Second * 10
+	if val, exists := os.LookupEnv("shard_refresh_interval"); exists && len(val) > 0 {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, 0, err
+		}
+		if d <= 0 {
+			return nil, 0, fmt.Errorf("shard_refresh_interval must be greater than zero: %s", val)
+		}
+		interval = d
+	}
+
+	identity := os.Getenv("shard_identity")
+	if len(identity) == 0 {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, 0, err
+		}
+		identity = hostname
+
+		// DNS returns the address of each replica, rather than its
+		// hostname
+		if backend == "dns" {
+			addrs, err := net.LookupHost(hostname)
+			if err != nil {
+				return nil, 0, fmt.Errorf("unable to find the address of %s, set shard_identity: %w", hostname, err)
+			}
+			identity = addrs[0]
+		}
+	}
+
+	switch backend {
+	case "static":
+		members := crontypes.StaticMembership(strings.Split(os.Getenv("shard_members"), ","))
+		return crontypes.NewSharder(identity, members), interval, nil
+	case "file":
+		dir := os.Getenv("shard_dir")
+		if len(dir) == 0 {
+			dir = filepath.Join(stateDir, "shards")
+		}
+		return crontypes.NewSharder(identity, crontypes.NewFileMembership(dir, identity, interval*3)), interval, nil
+	case "dns":
+		host := os.Getenv("shard_service")
+		if len(host) == 0 {
+			return nil, 0, fmt.Errorf("shard_service must be set to use dns for shard_membership")
+		}
+		return crontypes.NewSharder(identity, crontypes.NewDNSMembership(host)), interval, nil
+	}
+
+	return nil, 0, fmt.Errorf("unknown shard_membership: %q, use \"static\", \"file\" or \"dns\"", backend)
+}
@@ -5,10 +5,12 @@ go 1.23.0
 toolchain go1.23.3
 
 require (
+	github.com/alexellis/go-execute/v2 v2.2.1
 	github.com/openfaas/connector-sdk v0.8.0
 	github.com/openfaas/faas-cli v0.0.0-20250116111659-b368a1ccedbb
 	github.com/openfaas/faas-provider v0.25.4
 	github.com/openfaas/go-sdk v0.2.14
+	github.com/prometheus/client_golang v1.20.5
 	github.com/robfig/cron/v3 v3.0.1
 )
 
@@ -20,7 +22,6 @@ require (
 	github.com/ProtonMail/go-crypto v1.1.3 // indirect
 	github.com/VividCortex/ewma v1.2.0 // indirect
 	github.com/alexellis/arkade v0.0.0-20250120150820-889135fd0412 // indirect
-	github.com/alexellis/go-execute/v2 v2.2.1 // indirect
 	github.com/alexellis/hmac v1.3.0 // indirect
 	github.com/alexellis/hmac/v2 v2.0.0 // indirect
 	github.com/beorn7/perks v1.0.1 // indirect
@@ -73,7 +74,6 @@ require (
 	github.com/otiai10/mint v1.6.3 // indirect
 	github.com/pjbgf/sha1cd v0.3.0 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/prometheus/client_golang v1.20.5 // indirect
 	github.com/prometheus/client_model v0.6.1 // indirect
 	github.com/prometheus/common v0.62.0 // indirect
 	github.com/prometheus/procfs v0.15.1 // indirect
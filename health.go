@@ -0,0 +1,99 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// health tracks the reconcile loop and the scheduler for the liveness
+// and readiness endpoints
+type health struct {
+	started time.Time
+
+	// threshold is how long the reconcile loop can go without
+	// completing a pass, or the scheduler without a heartbeat, before
+	// the connector is no longer live
+	threshold time.Duration
+
+	// heartbeat returns the last time the scheduler dispatched a job
+	heartbeat func() time.Time
+
+	// lastPass is the Unix time in nanoseconds of the last pass of the
+	// reconcile loop, whatever its result, zero until the first one
+	lastPass atomic.Int64
+
+	// listed is set once the namespaces have been listed from the
+	// gateway
+	listed atomic.Bool
+}
+
+func newHealth(threshold time.Duration, heartbeat func() time.Time) *health {
+	return &health{
+		started:   time.Now(),
+		threshold: threshold,
+		heartbeat: heartbeat,
+	}
+}
+
+// observeReconcile records a pass over the gateway's functions. Errors
+// from the gateway do not count against liveness, as restarting the
+// connector would not fix them, and are reported by the metrics instead.
+func (h *health) observeReconcile(r reconcileResult) {
+	h.lastPass.Store(time.Now().UnixNano())
+
+	if r.listed {
+		h.listed.Store(true)
+	}
+}
+
+// ready passes once the connector has listed the namespaces on the
+// gateway, so that its schedules reflect the deployed functions
+func (h *health) ready(w http.ResponseWriter, r *http.Request) {
+	if !h.listed.Load() {
+		http.Error(w, "waiting for the namespaces to be listed", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Write([]byte("OK"))
+}
+
+// live fails when the reconcile loop has stalled, or the scheduler has
+// not dispatched its heartbeat, within the threshold
+func (h *health) live(w http.ResponseWriter, r *http.Request) {
+	if err := h.stalled(time.Now()); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Write([]byte("OK"))
+}
+
+func (h *health) stalled(now time.Time) error {
+	last := h.started
+	if pass := h.lastPass.Load(); pass != 0 {
+		last = time.Unix(0, pass)
+	}
+	if since := now.Sub(last); since > h.threshold {
+		return fmt.Errorf("reconcile loop has not completed a pass for %s", since.Round(time.Second))
+	}
+
+	beat := h.heartbeat()
+	if beat.IsZero() {
+		beat = h.started
+	}
+	if since := now.Sub(beat); since > h.threshold {
+		return fmt.Errorf("scheduler has not dispatched a job for %s", since.Round(time.Second))
+	}
+
+	return nil
+}
+
+func (h *health) register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /healthz", h.live)
+	mux.HandleFunc("GET /readyz", h.ready)
+}
@@ -10,14 +10,22 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
 	"time"
 
+	// Embed the IANA time zone database so that schedule_timezone
+	// works in images which do not ship tzdata
+	_ "time/tzdata"
+
 	sdk "github.com/openfaas/go-sdk"
 
 	"github.com/openfaas/connector-sdk/types"
 	crontypes "github.com/openfaas/cron-connector/types"
 	"github.com/openfaas/cron-connector/version"
 	ptypes "github.com/openfaas/faas-provider/types"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // topic is the value of the "topic" annotation to look for
@@ -40,11 +48,43 @@ func main() {
 		}
 	}
 
+	defaults, err := getDefaults()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
 	sha, ver := version.GetReleaseInfo()
 	log.Printf("Version: %s\tCommit: %s\n", sha, ver)
 	log.Printf("Gateway URL: %s", config.GatewayURL)
 	log.Printf("Async Invocation: %v", config.AsyncFunctionInvocation)
 	log.Printf("Rebuild interval: %s\tRebuild timeout: %s", config.RebuildInterval, rebuildTimeout)
+	log.Printf("Schedule format: %s\tInvocation timeout: %s", defaults.ScheduleFormat, defaults.Timeout)
+	log.Printf("Jitter: %s\tJitter mode: %s", defaults.Jitter, defaults.JitterMode)
+
+	httpPort, err := getHTTPPort()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	shutdownGracePeriod, err := getShutdownGracePeriod()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	livenessThreshold, err := getLivenessThreshold(config.RebuildInterval)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	historyLimits, historyBodyLimit, err := getHistoryConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+		os.Exit(1)
+	}
 
 	httpClient := types.MakeClient(config.UpstreamTimeout)
 	invoker := types.NewInvoker(
@@ -55,22 +95,50 @@ func main() {
 		config.PrintRequestBody,
 		"openfaas-ce/cron-connector")
 
-	go func() {
-		for {
-			r := <-invoker.Responses
-			if r.Error != nil {
-				log.Printf("Error with %s: %s", r.Function, r.Error)
-			} else {
-				duration := fmt.Sprintf("%.2fs", r.Duration.Seconds())
-				if r.Duration < time.Second*1 {
-					duration = fmt.Sprintf("%dms", r.Duration.Milliseconds())
-				}
-				log.Printf("Response: %s [%d] (%s)",
-					r.Function,
-					r.Status,
-					duration)
+	stateDir := getStateDir()
+	log.Printf("State directory: %s", stateDir)
+
+	history, err := crontypes.NewRunHistory(filepath.Join(stateDir, "history.jsonl"), historyLimits)
+	if err != nil {
+		log.Printf("Error loading run history, runs will not be recorded: %s", err)
+	}
+
+	m := newMetrics(prometheus.DefaultRegisterer)
+
+	handleResponse := func(r types.InvokerResponse) {
+		m.observeResponse(r)
+
+		if history != nil {
+			if err := history.Add(crontypes.NewRunRecord(r, historyBodyLimit)); err != nil {
+				log.Printf("Error recording run of %s: %s", r.Function, err)
 			}
 		}
+
+		attempt := ""
+		if run, ok := crontypes.RunFromContext(r.Context); ok && run.Attempt > 1 {
+			attempt = fmt.Sprintf(" attempt %d", run.Attempt)
+		}
+
+		if r.Error != nil {
+			log.Printf("Error with %s%s: %s", r.Function, attempt, r.Error)
+		} else {
+			duration := fmt.Sprintf("%.2fs", r.Duration.Seconds())
+			if r.Duration < time.Second*1 {
+				duration = fmt.Sprintf("%dms", r.Duration.Milliseconds())
+			}
+			log.Printf("Response: %s [%d] (%s)%s",
+				r.Function,
+				r.Status,
+				duration,
+				attempt)
+		}
+	}
+
+	stopResponses := make(chan struct{})
+	responsesDone := make(chan struct{})
+	go func() {
+		defer close(responsesDone)
+		processResponses(invoker.Responses, stopResponses, handleResponse)
 	}()
 
 	auth, err := crontypes.GetClientAuth()
@@ -78,18 +146,158 @@ func main() {
 		log.Fatalf("Failed to get auth credentials: %s", err)
 	}
 
-	cronScheduler := crontypes.NewScheduler()
+	schedulerOpts := []crontypes.SchedulerOption{}
+
+	runState, err := crontypes.NewRunState(filepath.Join(stateDir, "last_runs.json"))
+	if err != nil {
+		log.Printf("Error loading run state, missed runs will not be caught up on: %s", err)
+	} else {
+		schedulerOpts = append(schedulerOpts, crontypes.WithRunState(runState))
+	}
+
+	pauseState, err := crontypes.NewPauseState(filepath.Join(stateDir, "paused.json"))
+	if err != nil {
+		log.Printf("Error loading pause state, functions cannot be paused: %s", err)
+	} else {
+		schedulerOpts = append(schedulerOpts, crontypes.WithPauseState(pauseState))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	leaderElection, err := getLeaderElection(stateDir)
+	if err != nil {
+		log.Fatalf("Failed to set up leader election: %s", err)
+	}
+	if leaderElection != nil {
+		log.Printf("Leader election: enabled")
+		schedulerOpts = append(schedulerOpts, crontypes.WithLeader(leaderElection.IsLeader))
+		go leaderElection.Run(ctx)
+	}
+
+	runLock, err := getRunLock(stateDir)
+	if err != nil {
+		log.Fatalf("Failed to set up run lock: %s", err)
+	}
+	if runLock != nil {
+		log.Printf("Run lock: %s", os.Getenv("run_lock"))
+		schedulerOpts = append(schedulerOpts, crontypes.WithRunLock(runLock))
+	}
+
+	sharder, shardRefreshInterval, err := getSharder(stateDir)
+	if err != nil {
+		log.Fatalf("Failed to set up sharding: %s", err)
+	}
+	if sharder != nil {
+		log.Printf("Sharding: %s", os.Getenv("shard_membership"))
+		if err := sharder.Refresh(ctx); err != nil {
+			log.Printf("Error listing shard members: %s", err)
+		}
+		go sharder.Run(ctx, shardRefreshInterval)
+	}
+
+	cronScheduler := crontypes.NewScheduler(schedulerOpts...)
 	cronScheduler.Start()
 
+	running := &runningFunctions{}
+
+	var admin *adminAPI
+	if getAdminAPIEnabled() {
+		admin = &adminAPI{
+			scheduler:      cronScheduler,
+			running:        running,
+			invoker:        invoker,
+			history:        history,
+			tokenFile:      getAdminTokenFile(),
+			leaderElection: leaderElection != nil,
+			runLock:        runLock != nil,
+			sharder:        sharder,
+		}
+
+		if len(admin.tokenFile) > 0 {
+			log.Printf("Admin API: enabled, token file: %s", admin.tokenFile)
+		} else {
+			log.Printf("Admin API: enabled, read-only as admin_token_file is not set")
+		}
+	}
+
+	h := newHealth(livenessThreshold, cronScheduler.LastHeartbeat)
+	log.Printf("Liveness threshold: %s", livenessThreshold)
+
+	server := newServer(httpPort, h, admin)
+	go func() {
+		log.Printf("Listening on port: %d", httpPort)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Error starting HTTP server: %s", err)
+		}
+	}()
+
 	u, err := url.Parse(config.GatewayURL)
 	if err != nil {
 		log.Fatalf("Failed to parse gateway URL: %s", err)
 	}
 
-	if err := startFunctionProbe(u, config.RebuildInterval, rebuildTimeout, topic, config, cronScheduler, invoker, auth); err != nil {
+	onReconcile := func(r reconcileResult) {
+		m.observeReconcile(r)
+		h.observeReconcile(r)
+	}
+
+	if err := startFunctionProbe(ctx, u, config.RebuildInterval, rebuildTimeout, topic, defaults, config, cronScheduler, sharder, running, invoker, auth, onReconcile); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
 		os.Exit(1)
 	}
+
+	log.Printf("Shutting down, waiting up to %s for runs in flight", shutdownGracePeriod)
+
+	graceCtx, cancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
+	defer cancel()
+
+	for _, run := range cronScheduler.Stop(graceCtx) {
+		log.Printf("Abandoned: %s.%s run %s scheduled for %s", run.Function, run.Namespace, run.ID, run.Scheduled.Format(time.RFC3339))
+	}
+
+	if runState != nil {
+		if err := runState.Close(); err != nil {
+			log.Printf("Error saving run state: %s", err)
+		}
+	}
+
+	close(stopResponses)
+	<-responsesDone
+
+	if history != nil {
+		if err := history.Close(); err != nil {
+			log.Printf("Error closing run history: %s", err)
+		}
+	}
+
+	serverCtx, cancelServer := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancelServer()
+	if err := server.Shutdown(serverCtx); err != nil {
+		log.Printf("Error stopping HTTP server: %s", err)
+	}
+
+	log.Printf("Shutdown complete")
+}
+
+// processResponses passes each response from the invoker to handle until
+// stop is closed, then handles any responses which are already waiting
+func processResponses(responses <-chan types.InvokerResponse, stop <-chan struct{}, handle func(types.InvokerResponse)) {
+	for {
+		select {
+		case r := <-responses:
+			handle(r)
+		case <-stop:
+			for {
+				select {
+				case r := <-responses:
+					handle(r)
+				default:
+					return
+				}
+			}
+		}
+	}
 }
 
 func gatewayRoute(config *types.ControllerConfig) string {
@@ -112,46 +320,78 @@ func (auth *BasicAuth) Set(req *http.Request) error {
 	return nil
 }
 
-func startFunctionProbe(gatewayURL *url.URL, interval time.Duration, probeTimeout time.Duration, topic string, c *types.ControllerConfig, cronScheduler *crontypes.Scheduler, invoker *types.Invoker, auth sdk.ClientAuth) error {
+func startFunctionProbe(ctx context.Context, gatewayURL *url.URL, interval time.Duration, probeTimeout time.Duration, topic string, defaults crontypes.Defaults, c *types.ControllerConfig, cronScheduler *crontypes.Scheduler, sharder *crontypes.Sharder, running *runningFunctions, invoker *types.Invoker, auth sdk.ClientAuth, onReconcile func(reconcileResult)) error {
 	runningFuncs := make(crontypes.ScheduledFunctions, 0)
+	invalid := make(invalidFunctions)
 
 	httpClient := &http.Client{}
 	httpClient.Timeout = probeTimeout
 
 	sdkClient := sdk.NewClient(gatewayURL, auth, httpClient)
 
-	ctx := context.Background()
-
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
-		<-ticker.C
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+
+		// Until the members are known, any function could be
+		// scheduled on another replica too
+		if sharder != nil && !sharder.Ready() {
+			log.Printf("Waiting for shard members")
+			onReconcile(reconcileResult{waiting: true, scheduled: len(runningFuncs)})
+			continue
+		}
 
 		namespaces, err := sdkClient.GetNamespaces(ctx)
 		if err != nil {
 			log.Printf("error listing namespaces: %s", err)
+			onReconcile(reconcileResult{scheduled: len(runningFuncs)})
 			continue
 		}
 
+		result := reconcileResult{listed: true, namespaces: make(map[string]bool, len(namespaces))}
 		for _, namespace := range namespaces {
 			functions, err := sdkClient.GetFunctions(ctx, namespace)
+			result.namespaces[namespace] = err == nil
 			if err != nil {
 				log.Printf("error listing functions in %s: %s", namespace, err)
 				continue
 			}
 
-			newCronFunctions := requestsToCronFunctions(functions, namespace, topic)
-			addFuncs, deleteFuncs := getNewAndDeleteFuncs(newCronFunctions, runningFuncs, namespace)
+			newCronFunctions := requestsToCronFunctions(functions, namespace, topic, defaults, invalid)
+			if sharder != nil {
+				// Functions owned by another replica are removed,
+				// so they move when a replica joins or leaves
+				newCronFunctions = sharder.Filter(newCronFunctions)
+			}
+			addFuncs, updateFuncs, deleteFuncs := getNewAndDeleteFuncs(newCronFunctions, runningFuncs, namespace)
 
 			for _, function := range deleteFuncs {
 				log.Printf("Removed: %s [%s]",
 					function.Function.String(),
-					function.Function.Schedule)
+					function.Function.ScheduleString())
 
 				cronScheduler.Remove(function)
 			}
 
+			updatedScheduledFuncs := make(crontypes.ScheduledFunctions, 0)
+
+			for _, function := range updateFuncs {
+				f, err := cronScheduler.Update(function)
+				if err != nil {
+					log.Printf("can't update function: %s, %s", function.Function.String(), err)
+					continue
+				}
+
+				updatedScheduledFuncs = append(updatedScheduledFuncs, f)
+				log.Printf("Updated: %s [%s] %s", f.Function.String(), f.Function.ScheduleString(), f.Function.PayloadSummary())
+			}
+
 			newScheduledFuncs := make(crontypes.ScheduledFunctions, 0)
 
 			for _, function := range addFuncs {
@@ -162,58 +402,146 @@ func startFunctionProbe(gatewayURL *url.URL, interval time.Duration, probeTimeou
 				}
 
 				newScheduledFuncs = append(newScheduledFuncs, f)
-				log.Printf("Added: %s [%s]", function.String(), function.Schedule)
+
+				suspended := ""
+				if function.Suspended {
+					suspended = " (suspended)"
+				}
+				log.Printf("Added: %s [%s] %s%s", function.String(), function.ScheduleString(), function.PayloadSummary(), suspended)
 			}
 
-			runningFuncs = updateScheduledFunctions(runningFuncs, newScheduledFuncs, deleteFuncs)
+			runningFuncs = updateScheduledFunctions(runningFuncs, newScheduledFuncs, updatedScheduledFuncs, deleteFuncs)
+			running.Set(runningFuncs)
+		}
+
+		result.scheduled = len(runningFuncs)
+		onReconcile(result)
+	}
+}
+
+// reconcileResult describes one pass of the loop in startFunctionProbe
+type reconcileResult struct {
+	// waiting is true when the pass was skipped until the shard
+	// members are known
+	waiting bool
+
+	// listed is true when the namespaces were listed from the gateway
+	listed bool
+
+	// namespaces holds whether the functions in each namespace were
+	// listed
+	namespaces map[string]bool
+
+	// scheduled is the number of schedules which are running
+	scheduled int
+}
+
+// ok returns true when the functions in every namespace were listed
+func (r reconcileResult) ok() bool {
+	if !r.listed {
+		return false
+	}
+
+	for _, ok := range r.namespaces {
+		if !ok {
+			return false
 		}
 	}
+
+	return true
 }
 
 // requestsToCronFunctions converts an array of types.FunctionStatus object
-// to CronFunction, ignoring those that cannot be converted
-func requestsToCronFunctions(functions []ptypes.FunctionStatus, namespace string, topic string) crontypes.CronFunctions {
+// to CronFunctions, one per schedule expression, ignoring those that cannot
+// be converted. Errors for functions with the connector's topic are given
+// to invalid, when set, so that they can be logged.
+func requestsToCronFunctions(functions []ptypes.FunctionStatus, namespace string, topic string, defaults crontypes.Defaults, invalid invalidFunctions) crontypes.CronFunctions {
 	newCronFuncs := make(crontypes.CronFunctions, 0)
+	errs := make(map[string]invalidFunction)
 	for _, function := range functions {
-		cF, err := crontypes.ToCronFunction(function, namespace, topic)
+		cFs, err := crontypes.ToCronFunctions(function, namespace, topic, defaults)
 		if err != nil {
+			if function.Annotations != nil && (*function.Annotations)["topic"] == topic {
+				errs[function.Name] = invalidFunction{specHash: crontypes.SpecHash(function), err: err.Error()}
+			}
 			continue
 		}
-		newCronFuncs = append(newCronFuncs, cF)
+		newCronFuncs = append(newCronFuncs, cFs...)
 	}
+
+	if invalid != nil {
+		invalid.update(namespace, errs)
+	}
+
 	return newCronFuncs
 }
 
+// invalidFunction is the error for a function which could not be
+// converted, along with the spec it was found for
+type invalidFunction struct {
+	specHash string
+	err      string
+}
+
+// invalidFunctions holds the errors of the functions in each namespace which
+// could not be converted, so that each error is logged once rather than on
+// every rebuild, and again only when the function's spec or error changes
+type invalidFunctions map[string]map[string]invalidFunction
+
+// update logs the errors in namespace which were not logged before, and
+// forgets functions which are no longer invalid
+func (i invalidFunctions) update(namespace string, errs map[string]invalidFunction) {
+	for name, invalid := range errs {
+		if i[namespace][name] != invalid {
+			log.Printf("Not scheduled: %s.%s, %s", name, namespace, invalid.err)
+		}
+	}
+
+	i[namespace] = errs
+}
+
 // getNewAndDeleteFuncs takes new functions and running cron functions and returns
-// functions that need to be added and that need to be deleted
-func getNewAndDeleteFuncs(newFuncs crontypes.CronFunctions, oldFuncs crontypes.ScheduledFunctions, namespace string) (crontypes.CronFunctions, crontypes.ScheduledFunctions) {
+// functions that need to be added, updated and deleted. A function is updated
+// when the same schedule is running with a different spec, the updated
+// functions keep the entry of the running function.
+func getNewAndDeleteFuncs(newFuncs crontypes.CronFunctions, oldFuncs crontypes.ScheduledFunctions, namespace string) (crontypes.CronFunctions, crontypes.ScheduledFunctions, crontypes.ScheduledFunctions) {
 	addFuncs := make(crontypes.CronFunctions, 0)
+	updateFuncs := make(crontypes.ScheduledFunctions, 0)
 	deleteFuncs := make(crontypes.ScheduledFunctions, 0)
 
 	for _, function := range newFuncs {
-		if !oldFuncs.Contains(&function) {
+		running, found := oldFuncs.Find(&function)
+		switch {
+		case !found:
 			addFuncs = append(addFuncs, function)
+		case running.Function.SpecHash != function.SpecHash:
+			running.Function = function
+			updateFuncs = append(updateFuncs, running)
 		}
 	}
 
 	for _, function := range oldFuncs {
-		if !newFuncs.Contains(&function.Function) && function.Function.Namespace == namespace {
+		if _, found := newFuncs.Find(&function.Function); !found && function.Function.Namespace == namespace {
 			deleteFuncs = append(deleteFuncs, function)
 		}
 	}
 
-	return addFuncs, deleteFuncs
+	return addFuncs, updateFuncs, deleteFuncs
 }
 
 // updateScheduledFunctions updates the scheduled function with
-// added functions and removes deleted functions
-func updateScheduledFunctions(running, added, deleted crontypes.ScheduledFunctions) crontypes.ScheduledFunctions {
+// added and updated functions and removes deleted functions
+func updateScheduledFunctions(running, added, updated, deleted crontypes.ScheduledFunctions) crontypes.ScheduledFunctions {
 	updatedSchedule := make(crontypes.ScheduledFunctions, 0)
 
 	for _, function := range running {
-		if !deleted.Contains(&function.Function) {
-			updatedSchedule = append(updatedSchedule, function)
+		if deleted.Contains(&function.Function) {
+			continue
+		}
+		if f, found := updated.Find(&function.Function); found {
+			function = f
 		}
+		updatedSchedule = append(updatedSchedule, function)
 	}
 
 	updatedSchedule = append(updatedSchedule, added...)
@@ -3,14 +3,27 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/openfaas/connector-sdk/types"
 	cfunction "github.com/openfaas/cron-connector/types"
 	ptypes "github.com/openfaas/faas-provider/types"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-func TestgetNewAndDeleteFuncs(t *testing.T) {
+func TestGetNewAndDeleteFuncs(t *testing.T) {
 	newCronFunctions := make(cfunction.CronFunctions, 3)
 	defaultReq := ptypes.FunctionStatus{}
 	newCronFunctions[0] = cfunction.CronFunction{FuncData: defaultReq, Name: "test_function_unchanged", Namespace: "openfaas-fn", Schedule: "* * * * *"}
@@ -22,7 +35,7 @@ func TestgetNewAndDeleteFuncs(t *testing.T) {
 	oldFuncs[1] = cfunction.ScheduledFunction{Function: cfunction.CronFunction{FuncData: defaultReq, Name: "test_function_to_delete", Namespace: "openfaas-fn", Schedule: "* * * * *"}, ID: 0}
 	oldFuncs[2] = cfunction.ScheduledFunction{Function: cfunction.CronFunction{FuncData: defaultReq, Name: "test_function_to_update", Namespace: "openfaas-fn", Schedule: "* * * * *"}, ID: 0}
 
-	addFuncs, deleteFuncs := getNewAndDeleteFuncs(newCronFunctions, oldFuncs, "openfaas-fn")
+	addFuncs, _, deleteFuncs := getNewAndDeleteFuncs(newCronFunctions, oldFuncs, "openfaas-fn")
 	if !deleteFuncs.Contains(&oldFuncs[1].Function) {
 		t.Error("function was not deleted")
 	}
@@ -52,7 +65,7 @@ func TestNamespaceFuncs(t *testing.T) {
 	oldFuncs[1] = cfunction.ScheduledFunction{Function: cfunction.CronFunction{FuncData: defaultReq, Name: "test_function_to_delete", Namespace: "openfaas-fn", Schedule: "* * * * *"}, ID: 0}
 	oldFuncs[2] = cfunction.ScheduledFunction{Function: cfunction.CronFunction{FuncData: defaultReq, Name: "test_function_to_update", Namespace: "openfaas-fn", Schedule: "* * * * *"}, ID: 0}
 
-	addFuncs, deleteFuncs := getNewAndDeleteFuncs(newCronFunctions, oldFuncs, "openfaas-fn")
+	addFuncs, _, deleteFuncs := getNewAndDeleteFuncs(newCronFunctions, oldFuncs, "openfaas-fn")
 	if !deleteFuncs.Contains(&oldFuncs[1].Function) {
 		t.Error("function was not deleted")
 	}
@@ -101,3 +114,751 @@ func TestGatewayRoute_Async(t *testing.T) {
 	}
 
 }
+
+func TestGetNewAndDeleteFuncs_MultipleSchedules(t *testing.T) {
+	annotations := map[string]string{
+		"topic":    "cron-function",
+		"schedule": "0 9 * * 1-5; 0 12 * * 0,6",
+	}
+	functions := []ptypes.FunctionStatus{{Name: "report", Annotations: &annotations}}
+
+	running := make(cfunction.ScheduledFunctions, 0)
+	for i, function := range requestsToCronFunctions(functions, "openfaas-fn", "cron-function", cfunction.Defaults{}, nil) {
+		running = append(running, cfunction.ScheduledFunction{Function: function, ID: cfunction.EntryID(i + 1)})
+	}
+
+	if len(running) != 2 {
+		t.Fatalf("expected 2 scheduled functions, got %d", len(running))
+	}
+
+	updated := map[string]string{
+		"topic":    "cron-function",
+		"schedule": "0 12 * * 0,6; 0 18 * * *",
+	}
+	functions = []ptypes.FunctionStatus{{Name: "report", Annotations: &updated}}
+
+	addFuncs, updateFuncs, deleteFuncs := getNewAndDeleteFuncs(requestsToCronFunctions(functions, "openfaas-fn", "cron-function", cfunction.Defaults{}, nil), running, "openfaas-fn")
+
+	if len(addFuncs) != 1 || addFuncs[0].Schedule != "0 18 * * *" {
+		t.Errorf("expected only the new expression to be added, got: %v", addFuncs)
+	}
+
+	if len(deleteFuncs) != 1 || deleteFuncs[0].Function.Schedule != "0 9 * * 1-5" {
+		t.Errorf("expected only the removed expression to be deleted, got: %v", deleteFuncs)
+	}
+
+	if len(updateFuncs) != 0 {
+		t.Errorf("expected the unchanged expression not to be updated, got: %v", updateFuncs)
+	}
+
+	remaining := updateScheduledFunctions(running, cfunction.ScheduledFunctions{}, cfunction.ScheduledFunctions{}, deleteFuncs)
+	if len(remaining) != 1 || remaining[0].ID != 2 {
+		t.Errorf("expected the unchanged expression to keep its entry, got: %v", remaining)
+	}
+}
+
+func TestGetNewAndDeleteFuncs_SpecChanged(t *testing.T) {
+	toCronFunctions := func(image string) cfunction.CronFunctions {
+		annotations := map[string]string{
+			"topic":    "cron-function",
+			"schedule": "0 2 * * *",
+		}
+		functions := []ptypes.FunctionStatus{{Name: "report", Image: image, Annotations: &annotations}}
+		return requestsToCronFunctions(functions, "openfaas-fn", "cron-function", cfunction.Defaults{}, nil)
+	}
+
+	running := cfunction.ScheduledFunctions{{Function: toCronFunctions("report:1.0")[0], ID: 7}}
+
+	testcases := []struct {
+		Name    string
+		Image   string
+		Updated bool
+	}{
+		{Name: "unchanged", Image: "report:1.0", Updated: false},
+		{Name: "new image", Image: "report:1.1", Updated: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			addFuncs, updateFuncs, deleteFuncs := getNewAndDeleteFuncs(toCronFunctions(tc.Image), running, "openfaas-fn")
+
+			if len(addFuncs) != 0 || len(deleteFuncs) != 0 {
+				t.Fatalf("expected no functions to be added or deleted, got: %v %v", addFuncs, deleteFuncs)
+			}
+			if updated := len(updateFuncs) == 1; updated != tc.Updated {
+				t.Fatalf("expected updated: %v, got: %v", tc.Updated, updateFuncs)
+			}
+			if !tc.Updated {
+				return
+			}
+
+			if updateFuncs[0].ID != 7 || updateFuncs[0].Function.FuncData.Image != tc.Image {
+				t.Errorf("expected the running entry with the new spec, got: %+v", updateFuncs[0])
+			}
+
+			remaining := updateScheduledFunctions(running, cfunction.ScheduledFunctions{}, updateFuncs, cfunction.ScheduledFunctions{})
+			if len(remaining) != 1 || remaining[0].Function.FuncData.Image != tc.Image {
+				t.Errorf("expected the updated function to replace the running one, got: %v", remaining)
+			}
+		})
+	}
+}
+
+func TestGetNewAndDeleteFuncs_Sharded(t *testing.T) {
+	annotations := map[string]string{
+		"topic":    "cron-function",
+		"schedule": "0 2 * * *",
+	}
+	functions := []ptypes.FunctionStatus{}
+	for i := 0; i < 50; i++ {
+		functions = append(functions, ptypes.FunctionStatus{Name: fmt.Sprintf("fn-%d", i), Annotations: &annotations})
+	}
+	cronFunctions := requestsToCronFunctions(functions, "openfaas-fn", "cron-function", cfunction.Defaults{}, nil)
+
+	members := cfunction.StaticMembership{"a", "b"}
+	running := map[string]cfunction.ScheduledFunctions{}
+
+	reconcile := func(member string) {
+		sharder := cfunction.NewSharder(member, members)
+		sharder.Refresh(context.Background())
+
+		addFuncs, _, deleteFuncs := getNewAndDeleteFuncs(sharder.Filter(cronFunctions), running[member], "openfaas-fn")
+		added := cfunction.ScheduledFunctions{}
+		for _, function := range addFuncs {
+			added = append(added, cfunction.ScheduledFunction{Function: function})
+		}
+		running[member] = updateScheduledFunctions(running[member], added, cfunction.ScheduledFunctions{}, deleteFuncs)
+	}
+
+	reconcile("a")
+	reconcile("b")
+	if len(running["a"]) == 0 || len(running["b"]) == 0 || len(running["a"])+len(running["b"]) != len(cronFunctions) {
+		t.Fatalf("expected the functions to be split between a and b, got: %d and %d", len(running["a"]), len(running["b"]))
+	}
+
+	// a leaves, so b takes over its functions
+	members = cfunction.StaticMembership{"b"}
+	reconcile("b")
+
+	if len(running["b"]) != len(cronFunctions) {
+		t.Errorf("expected b to schedule every function once a left, got: %d", len(running["b"]))
+	}
+}
+
+func TestListSchedules_FiltersByNamespace(t *testing.T) {
+	scheduler := cfunction.NewScheduler()
+
+	running := &runningFunctions{}
+	functions := make(cfunction.ScheduledFunctions, 0)
+	for _, namespace := range []string{"openfaas-fn", "staging"} {
+		f, err := scheduler.AddCronFunction(cfunction.CronFunction{Name: "nightly", Namespace: namespace, Schedule: "0 2 * * *", ScheduleFormat: cfunction.StandardFormat}, nil)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		functions = append(functions, f)
+	}
+	running.Set(functions)
+
+	srv := httptest.NewServer(newServer(0, nil, &adminAPI{scheduler: scheduler, running: running}).Handler)
+	defer srv.Close()
+
+	testcases := []struct {
+		Query string
+		Want  []string
+	}{
+		{Query: "", Want: []string{"openfaas-fn", "staging"}},
+		{Query: "?namespace=staging", Want: []string{"staging"}},
+		{Query: "?namespace=missing", Want: []string{}},
+	}
+
+	for _, tc := range testcases {
+		res, err := http.Get(srv.URL + "/schedules" + tc.Query)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		schedules := []cfunction.ScheduleStatus{}
+		json.NewDecoder(res.Body).Decode(&schedules)
+		res.Body.Close()
+
+		namespaces := []string{}
+		for _, schedule := range schedules {
+			namespaces = append(namespaces, schedule.Namespace)
+		}
+
+		if !slices.Equal(namespaces, tc.Want) {
+			t.Errorf("%q expected: %v, got: %v", tc.Query, tc.Want, namespaces)
+		}
+	}
+}
+
+func TestAdminAPI_Disabled(t *testing.T) {
+	srv := httptest.NewServer(newServer(0, nil, nil).Handler)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/schedules")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected: %d, got: %d", http.StatusNotFound, res.StatusCode)
+	}
+}
+
+func TestListRuns_RequiresAdminToken(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "admin-token")
+	if err := os.WriteFile(tokenFile, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := cfunction.NewRunHistory(filepath.Join(dir, "history.jsonl"), cfunction.HistoryLimits{MaxRuns: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer history.Close()
+
+	testcases := []struct {
+		Name   string
+		Admin  *adminAPI
+		Token  string
+		Status int
+	}{
+		{Name: "admin API disabled", Admin: nil, Token: "secret", Status: http.StatusNotFound},
+		{Name: "no admin token file", Admin: &adminAPI{history: history}, Token: "secret", Status: http.StatusNotFound},
+		{Name: "no token", Admin: &adminAPI{history: history, tokenFile: tokenFile}, Status: http.StatusUnauthorized},
+		{Name: "admin token", Admin: &adminAPI{history: history, tokenFile: tokenFile}, Token: "secret", Status: http.StatusOK},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			srv := httptest.NewServer(newServer(0, nil, tc.Admin).Handler)
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/runs", nil)
+			if len(tc.Token) > 0 {
+				req.Header.Set("Authorization", "Bearer "+tc.Token)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != tc.Status {
+				t.Errorf("expected: %d, got: %d", tc.Status, res.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRunNow(t *testing.T) {
+	triggers := make(chan string, 1)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		triggers <- r.Header.Get(cfunction.TriggerHeader)
+	}))
+	defer gateway.Close()
+
+	invoker := &types.Invoker{
+		Client:     gateway.Client(),
+		GatewayURL: gateway.URL + "/function",
+		Responses:  make(chan types.InvokerResponse, 1),
+	}
+
+	tokenFile := filepath.Join(t.TempDir(), "admin-token")
+	if err := os.WriteFile(tokenFile, []byte("secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	annotations := map[string]string{"topic": "cron-function"}
+	running := &runningFunctions{}
+	running.Set(cfunction.ScheduledFunctions{{
+		Function: cfunction.CronFunction{
+			FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+			Name:      "nightly",
+			Namespace: "openfaas-fn",
+			Schedule:  "0 2 * * *",
+		},
+	}})
+
+	admin := &adminAPI{
+		scheduler: cfunction.NewScheduler(),
+		running:   running,
+		invoker:   invoker,
+		tokenFile: tokenFile,
+	}
+
+	srv := httptest.NewServer(newServer(0, nil, admin).Handler)
+	defer srv.Close()
+
+	testcases := []struct {
+		Name   string
+		Path   string
+		Token  string
+		Status int
+	}{
+		{Name: "no token", Path: "/schedules/openfaas-fn/nightly/run", Status: http.StatusUnauthorized},
+		{Name: "wrong token", Path: "/schedules/openfaas-fn/nightly/run", Token: "guess", Status: http.StatusUnauthorized},
+		{Name: "unknown function", Path: "/schedules/openfaas-fn/hourly/run", Token: "secret", Status: http.StatusNotFound},
+		{Name: "run now", Path: "/schedules/openfaas-fn/nightly/run", Token: "secret", Status: http.StatusAccepted},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+tc.Path, nil)
+			if len(tc.Token) > 0 {
+				req.Header.Set("Authorization", "Bearer "+tc.Token)
+			}
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != tc.Status {
+				t.Errorf("expected: %d, got: %d", tc.Status, res.StatusCode)
+			}
+		})
+	}
+
+	select {
+	case trigger := <-triggers:
+		if trigger != "manual" {
+			t.Errorf("expected: %s, got: %s", "manual", trigger)
+		}
+	case <-time.After(time.Second * 2):
+		t.Fatal("expected the function to be invoked")
+	}
+
+	r := <-invoker.Responses
+	if run, ok := cfunction.RunFromContext(r.Context); !ok || !run.Manual {
+		t.Error("expected the response to be for a manual run")
+	}
+}
+
+func TestPauseAndResume(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "admin-token")
+	if err := os.WriteFile(tokenFile, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	pauseState, err := cfunction.NewPauseState(filepath.Join(dir, "paused.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler := cfunction.NewScheduler(cfunction.WithPauseState(pauseState))
+
+	function := cfunction.CronFunction{Name: "nightly", Namespace: "openfaas-fn", Schedule: "0 2 * * *"}
+	running := &runningFunctions{}
+	running.Set(cfunction.ScheduledFunctions{{Function: function}})
+
+	srv := httptest.NewServer(newServer(0, nil, &adminAPI{scheduler: scheduler, running: running, tokenFile: tokenFile}).Handler)
+	defer srv.Close()
+
+	testcases := []struct {
+		Action     string
+		Status     int
+		WantPaused bool
+	}{
+		{Action: "pause", Status: http.StatusNoContent, WantPaused: true},
+		{Action: "pause", Status: http.StatusNoContent, WantPaused: true},
+		{Action: "resume", Status: http.StatusNoContent, WantPaused: false},
+	}
+
+	for _, tc := range testcases {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/schedules/openfaas-fn/nightly/"+tc.Action, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != tc.Status {
+			t.Errorf("%s expected: %d, got: %d", tc.Action, tc.Status, res.StatusCode)
+		}
+		if got := scheduler.Paused(function); got != tc.WantPaused {
+			t.Errorf("%s expected paused: %v, got: %v", tc.Action, tc.WantPaused, got)
+		}
+	}
+}
+
+func TestPauseAndResume_Replicated(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "admin-token")
+	if err := os.WriteFile(tokenFile, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sharder := cfunction.NewSharder("cron-connector-0", cfunction.StaticMembership{"cron-connector-0"})
+	if err := sharder.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	testcases := []struct {
+		Name    string
+		Admin   adminAPI
+		Setting string
+	}{
+		{Name: "run lock", Admin: adminAPI{runLock: true}, Setting: "run_lock"},
+		{Name: "leader election", Admin: adminAPI{leaderElection: true}, Setting: "leader_election"},
+		{Name: "sharding", Admin: adminAPI{sharder: sharder}, Setting: "shard_membership"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			function := cfunction.CronFunction{Name: "nightly", Namespace: "openfaas-fn", Schedule: "0 2 * * *"}
+			running := &runningFunctions{}
+			running.Set(cfunction.ScheduledFunctions{{Function: function}})
+
+			admin := tc.Admin
+			admin.scheduler = cfunction.NewScheduler()
+			admin.running = running
+			admin.tokenFile = tokenFile
+
+			srv := httptest.NewServer(newServer(0, nil, &admin).Handler)
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodPost, srv.URL+"/schedules/openfaas-fn/nightly/pause", nil)
+			req.Header.Set("Authorization", "Bearer secret")
+
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer res.Body.Close()
+			body, _ := io.ReadAll(res.Body)
+
+			if res.StatusCode != http.StatusConflict {
+				t.Errorf("expected: %d, got: %d", http.StatusConflict, res.StatusCode)
+			}
+			if !strings.Contains(string(body), tc.Setting) {
+				t.Errorf("expected %s to be named, got: %s", tc.Setting, body)
+			}
+			if admin.scheduler.Paused(function) {
+				t.Error("expected the function not to be paused")
+			}
+		})
+	}
+}
+
+func TestRunNow_ShardedElsewhere(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "admin-token")
+	if err := os.WriteFile(tokenFile, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sharder := cfunction.NewSharder("cron-connector-0", cfunction.StaticMembership{"cron-connector-0", "cron-connector-1"})
+	if err := sharder.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	name := ""
+	for i := 0; len(name) == 0; i++ {
+		if candidate := fmt.Sprintf("fn-%d", i); sharder.Owner("openfaas-fn", candidate) == "cron-connector-1" {
+			name = candidate
+		}
+	}
+
+	admin := &adminAPI{
+		scheduler: cfunction.NewScheduler(),
+		running:   &runningFunctions{},
+		tokenFile: tokenFile,
+		sharder:   sharder,
+	}
+	srv := httptest.NewServer(newServer(0, nil, admin).Handler)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/schedules/openfaas-fn/"+name+"/run", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer res.Body.Close()
+	body, _ := io.ReadAll(res.Body)
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("expected: %d, got: %d", http.StatusNotFound, res.StatusCode)
+	}
+	if !strings.Contains(string(body), "cron-connector-1") {
+		t.Errorf("expected the replica which schedules the function to be named, got: %s", body)
+	}
+}
+
+func TestMetrics_ObserveResponse(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newMetrics(reg)
+
+	scheduled := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+	run := cfunction.Run{Namespace: "openfaas-fn", Scheduled: scheduled, Started: scheduled.Add(2 * time.Second), Attempt: 1}
+	m.observeResponse(types.InvokerResponse{Context: cfunction.WithRun(context.Background(), run), Function: "nightly", Status: 200, Duration: time.Second})
+
+	run.Attempt = 0
+	m.observeResponse(types.InvokerResponse{Context: cfunction.WithRun(context.Background(), run), Function: "nightly", Status: 409})
+
+	got := gatherMetrics(t, reg)
+
+	want := map[string]float64{
+		`cron_connector_invocations_total{code="200",function="nightly",namespace="openfaas-fn"}`:      1,
+		`cron_connector_invocations_total{code="409",function="nightly",namespace="openfaas-fn"}`:      1,
+		`cron_connector_invocation_duration_seconds_count{function="nightly",namespace="openfaas-fn"}`: 1,
+		`cron_connector_schedule_lag_seconds_count{function="nightly",namespace="openfaas-fn"}`:        1,
+		`cron_connector_schedule_lag_seconds_sum{function="nightly",namespace="openfaas-fn"}`:          2,
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s expected: %f, got: %f", name, value, got[name])
+		}
+	}
+}
+
+func TestMetrics_ObserveReconcile(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newMetrics(reg)
+
+	m.observeReconcile(reconcileResult{listed: true, namespaces: map[string]bool{"openfaas-fn": true, "staging": true}, scheduled: 3})
+	m.observeReconcile(reconcileResult{listed: true, namespaces: map[string]bool{"openfaas-fn": true, "staging": false}, scheduled: 3})
+	m.observeReconcile(reconcileResult{scheduled: 3})
+	m.observeReconcile(reconcileResult{waiting: true, scheduled: 2})
+
+	got := gatherMetrics(t, reg)
+
+	want := map[string]float64{
+		`cron_connector_scheduled_functions`:                                        2,
+		`cron_connector_reconciles_total{namespace="openfaas-fn",result="success"}`: 2,
+		`cron_connector_reconciles_total{namespace="staging",result="success"}`:     1,
+		`cron_connector_reconciles_total{namespace="staging",result="failure"}`:     1,
+		`cron_connector_reconciles_total{namespace="",result="failure"}`:            1,
+	}
+	for name, value := range want {
+		if got[name] != value {
+			t.Errorf("%s expected: %f, got: %f", name, value, got[name])
+		}
+	}
+
+	last := time.Unix(int64(got["cron_connector_last_reconcile_success_timestamp_seconds"]), 0)
+	if time.Since(last) > time.Minute {
+		t.Errorf("expected the last reconcile to be recent, got: %s", last)
+	}
+}
+
+// gatherMetrics returns the value of each series in reg, keyed by its name
+// and labels, with the count and sum of histograms
+func gatherMetrics(t *testing.T, reg *prometheus.Registry) map[string]float64 {
+	t.Helper()
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	values := map[string]float64{}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := []string{}
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+			}
+
+			suffix := ""
+			if len(labels) > 0 {
+				suffix = "{" + strings.Join(labels, ",") + "}"
+			}
+
+			switch {
+			case metric.GetCounter() != nil:
+				values[family.GetName()+suffix] = metric.GetCounter().GetValue()
+			case metric.GetGauge() != nil:
+				values[family.GetName()+suffix] = metric.GetGauge().GetValue()
+			case metric.GetHistogram() != nil:
+				values[family.GetName()+"_count"+suffix] = float64(metric.GetHistogram().GetSampleCount())
+				values[family.GetName()+"_sum"+suffix] = metric.GetHistogram().GetSampleSum()
+			}
+		}
+	}
+
+	return values
+}
+
+func TestHealth_Stalled(t *testing.T) {
+	now := time.Now()
+
+	testcases := []struct {
+		Name      string
+		Started   time.Time
+		LastPass  time.Time
+		Heartbeat time.Time
+		WantErr   bool
+	}{
+		{Name: "starting up", Started: now.Add(-time.Minute)},
+		{Name: "never passed", Started: now.Add(-time.Hour), Heartbeat: now, WantErr: true},
+		{Name: "passing", Started: now.Add(-time.Hour), LastPass: now.Add(-time.Minute), Heartbeat: now},
+		{Name: "loop stalled", Started: now.Add(-time.Hour), LastPass: now.Add(-10 * time.Minute), Heartbeat: now, WantErr: true},
+		{Name: "scheduler stalled", Started: now.Add(-time.Hour), LastPass: now, Heartbeat: now.Add(-10 * time.Minute), WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			h := newHealth(5*time.Minute, func() time.Time { return tc.Heartbeat })
+			h.started = tc.Started
+			if !tc.LastPass.IsZero() {
+				h.lastPass.Store(tc.LastPass.UnixNano())
+			}
+
+			err := h.stalled(now)
+			if tc.WantErr && err == nil {
+				t.Error("expected an error")
+			}
+			if !tc.WantErr && err != nil {
+				t.Errorf("unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestHealth_GatewayErrors(t *testing.T) {
+	h := newHealth(5*time.Minute, time.Now)
+
+	srv := httptest.NewServer(newServer(0, h, nil).Handler)
+	defer srv.Close()
+
+	steps := []struct {
+		Name      string
+		Result    reconcileResult
+		WantReady int
+	}{
+		{Name: "waiting for shard members", Result: reconcileResult{waiting: true}, WantReady: http.StatusServiceUnavailable},
+		{Name: "gateway down", Result: reconcileResult{}, WantReady: http.StatusServiceUnavailable},
+		{Name: "a namespace failed", Result: reconcileResult{listed: true, namespaces: map[string]bool{"openfaas-fn": true, "staging": false}}, WantReady: http.StatusOK},
+		{Name: "gateway down again", Result: reconcileResult{}, WantReady: http.StatusOK},
+	}
+
+	for _, step := range steps {
+		h.observeReconcile(step.Result)
+
+		for path, want := range map[string]int{"/readyz": step.WantReady, "/healthz": http.StatusOK} {
+			res, err := http.Get(srv.URL + path)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != want {
+				t.Errorf("%s: %s expected: %d, got: %d", step.Name, path, want, res.StatusCode)
+			}
+		}
+	}
+}
+
+func TestProcessResponses_DrainsOnStop(t *testing.T) {
+	responses := make(chan types.InvokerResponse, 3)
+	stop := make(chan struct{})
+
+	handled := []string{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		processResponses(responses, stop, func(r types.InvokerResponse) {
+			handled = append(handled, r.Function)
+		})
+	}()
+
+	responses <- types.InvokerResponse{Function: "one"}
+	responses <- types.InvokerResponse{Function: "two"}
+	responses <- types.InvokerResponse{Function: "three"}
+	close(stop)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 2):
+		t.Fatal("expected processResponses to return")
+	}
+
+	if len(handled) != 3 {
+		t.Errorf("expected each pending response to be handled, got: %v", handled)
+	}
+}
+
+func TestGetHeaderSecretsPath(t *testing.T) {
+	testcases := []struct {
+		Name              string
+		HeaderSecretsPath string
+		SecretMountPath   string
+		AdminTokenFile    string
+		Want              string
+		WantErr           bool
+	}{
+		{Name: "not set", Want: ""},
+		{Name: "separate directory", HeaderSecretsPath: "/var/openfaas/header-secrets", Want: "/var/openfaas/header-secrets"},
+		{Name: "default credentials", HeaderSecretsPath: "/var/openfaas/secrets", WantErr: true},
+		{Name: "inside the credentials", HeaderSecretsPath: "/var/openfaas/secrets/headers", WantErr: true},
+		{Name: "parent of the credentials", HeaderSecretsPath: "/var/openfaas", WantErr: true},
+		{Name: "secret_mount_path", HeaderSecretsPath: "/etc/secrets/", SecretMountPath: "/etc/secrets", WantErr: true},
+		{Name: "admin token", HeaderSecretsPath: "/etc/admin", AdminTokenFile: "/etc/admin/token", WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Setenv("header_secrets_path", tc.HeaderSecretsPath)
+			t.Setenv("secret_mount_path", tc.SecretMountPath)
+			t.Setenv("admin_token_file", tc.AdminTokenFile)
+
+			got, err := getHeaderSecretsPath()
+			if tc.WantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got != tc.Want {
+				t.Errorf("expected: %s, got: %s", tc.Want, got)
+			}
+		})
+	}
+}
+
+func TestRequestsToCronFunctions_LogsErrorsOnce(t *testing.T) {
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	function := func(name, topic, timeout string) ptypes.FunctionStatus {
+		annotations := map[string]string{"topic": topic, "schedule": "0 2 * * *", "timeout": timeout}
+		return ptypes.FunctionStatus{Name: name, Annotations: &annotations}
+	}
+
+	invalid := make(invalidFunctions)
+	steps := []struct {
+		Name      string
+		Functions []ptypes.FunctionStatus
+		Want      int
+	}{
+		{Name: "invalid", Functions: []ptypes.FunctionStatus{function("nightly", "cron-function", "soon"), function("other", "kafka", "soon")}, Want: 1},
+		{Name: "unchanged", Functions: []ptypes.FunctionStatus{function("nightly", "cron-function", "soon")}, Want: 1},
+		{Name: "spec changed", Functions: []ptypes.FunctionStatus{function("nightly", "cron-function", "later")}, Want: 2},
+		{Name: "fixed", Functions: []ptypes.FunctionStatus{function("nightly", "cron-function", "30s")}, Want: 2},
+		{Name: "broken again", Functions: []ptypes.FunctionStatus{function("nightly", "cron-function", "later")}, Want: 3},
+	}
+
+	for _, step := range steps {
+		requestsToCronFunctions(step.Functions, "openfaas-fn", "cron-function", cfunction.Defaults{}, invalid)
+
+		if got := strings.Count(logs.String(), "Not scheduled: nightly.openfaas-fn"); got != step.Want {
+			t.Errorf("%s: expected the error to be logged %d times, got: %d\n%s", step.Name, step.Want, got, logs.String())
+		}
+	}
+
+	if strings.Contains(logs.String(), "other") {
+		t.Errorf("expected functions with another topic not to be logged, got: %s", logs.String())
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/openfaas/connector-sdk/types"
+	crontypes "github.com/openfaas/cron-connector/types"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics are exposed on /metrics for Prometheus
+type metrics struct {
+	invocations        *prometheus.CounterVec
+	invocationDuration *prometheus.HistogramVec
+	scheduleLag        *prometheus.HistogramVec
+	scheduledFunctions prometheus.Gauge
+	reconciles         *prometheus.CounterVec
+	lastReconcile      prometheus.Gauge
+}
+
+// newMetrics creates the connector's metrics and registers them with reg
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		invocations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cron_connector_invocations_total",
+			Help: "Attempts to invoke a function, by status code",
+		}, []string{"function", "namespace", "code"}),
+		invocationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cron_connector_invocation_duration_seconds",
+			Help:    "Time taken by each attempt to invoke a function",
+			Buckets: []float64{0.05, 0.1, 0.5, 1, 5, 10, 30, 60, 300, 900},
+		}, []string{"function", "namespace"}),
+		scheduleLag: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "cron_connector_schedule_lag_seconds",
+			Help:    "Time between when a run was scheduled for and when it started",
+			Buckets: []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 300},
+		}, []string{"function", "namespace"}),
+		scheduledFunctions: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cron_connector_scheduled_functions",
+			Help: "Schedules the connector is running, one for each expression of a function",
+		}),
+		reconciles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cron_connector_reconciles_total",
+			Help: "Passes over the functions in each namespace on the gateway, by result, the namespace is empty when the namespaces could not be listed",
+		}, []string{"namespace", "result"}),
+		lastReconcile: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cron_connector_last_reconcile_success_timestamp_seconds",
+			Help: "Unix time of the last pass over the gateway's functions which succeeded",
+		}),
+	}
+
+	reg.MustRegister(
+		m.invocations,
+		m.invocationDuration,
+		m.scheduleLag,
+		m.scheduledFunctions,
+		m.reconciles,
+		m.lastReconcile,
+	)
+
+	return m
+}
+
+// observeResponse records the outcome of an attempt to invoke a function
+func (m *metrics) observeResponse(r types.InvokerResponse) {
+	namespace := ""
+	run, hasRun := crontypes.RunFromContext(r.Context)
+	if hasRun {
+		namespace = run.Namespace
+	}
+
+	m.invocations.WithLabelValues(r.Function, namespace, strconv.Itoa(r.Status)).Inc()
+
+	// Skipped runs are never attempted
+	if !hasRun || run.Attempt == 0 {
+		return
+	}
+
+	m.invocationDuration.WithLabelValues(r.Function, namespace).Observe(r.Duration.Seconds())
+
+	// Lag is measured once per run, manual runs start when they are
+	// scheduled for
+	if run.Attempt == 1 && !run.Manual {
+		m.scheduleLag.WithLabelValues(r.Function, namespace).Observe(run.Started.Sub(run.Scheduled).Seconds())
+	}
+}
+
+// observeReconcile records a pass over the gateway's functions
+func (m *metrics) observeReconcile(r reconcileResult) {
+	m.scheduledFunctions.Set(float64(r.scheduled))
+
+	if r.waiting {
+		return
+	}
+
+	if !r.listed {
+		m.reconciles.WithLabelValues("", "failure").Inc()
+		return
+	}
+
+	for namespace, ok := range r.namespaces {
+		result := "success"
+		if !ok {
+			result = "failure"
+		}
+		m.reconciles.WithLabelValues(namespace, result).Inc()
+	}
+
+	if r.ok() {
+		m.lastReconcile.Set(float64(time.Now().Unix()))
+	}
+}
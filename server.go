@@ -0,0 +1,62 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	crontypes "github.com/openfaas/cron-connector/types"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// newServer returns the connector's HTTP server, which serves metrics, health
+// checks when health is not nil, and the admin API when admin is not nil
+func newServer(port int, health *health, admin *adminAPI) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	if health != nil {
+		health.register(mux)
+	}
+
+	if admin != nil {
+		admin.register(mux)
+	}
+
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: time.Second * 5,
+	}
+}
+
+// listRuns returns the runs in the history which match the query string
+func listRuns(history *crontypes.RunHistory) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, err := crontypes.ParseRunQuery(r.URL.Query())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if q.Limit == 0 {
+			q.Limit = 100
+		}
+
+		writeJSON(w, http.StatusOK, history.Query(q))
+	}
+}
+
+// writeJSON writes v as the body of the response with status
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing response: %s", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	cron "github.com/robfig/cron/v3"
+)
+
+// CatchupPolicy decides how runs which were missed while the connector
+// was down are made up for
+type CatchupPolicy string
+
+const (
+	// CatchupOnce runs the function once, for the latest missed run
+	CatchupOnce CatchupPolicy = "once"
+
+	// CatchupAll runs the function for each missed run, in order
+	CatchupAll CatchupPolicy = "all"
+)
+
+// maxCatchupRuns limits the runs made up for with CatchupAll, for
+// frequent schedules with a long starting deadline
+const maxCatchupRuns = 100
+
+// ParseCatchupPolicy returns the CatchupPolicy named by value, an empty
+// value selects CatchupOnce
+func ParseCatchupPolicy(value string) (CatchupPolicy, error) {
+	switch CatchupPolicy(value) {
+	case "", CatchupOnce:
+		return CatchupOnce, nil
+	case CatchupAll:
+		return CatchupAll, nil
+	}
+
+	return "", fmt.Errorf("unknown catch-up policy: %q, use %q or %q", value, CatchupOnce, CatchupAll)
+}
+
+// readCatchup parses the starting_deadline and catchup_policy annotations
+func readCatchup(annotations map[string]string) (time.Duration, CatchupPolicy, error) {
+	var deadline time.Duration
+	if v, ok := annotations["starting_deadline"]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return 0, "", fmt.Errorf("starting_deadline must be a positive duration: %q", v)
+		}
+		deadline = d
+	}
+
+	policy, err := ParseCatchupPolicy(annotations["catchup_policy"])
+	if err != nil {
+		return 0, "", err
+	}
+
+	return deadline, policy, nil
+}
+
+// stateKey identifies a scheduled function in the RunState
+func (c *CronFunction) stateKey() string {
+	return fmt.Sprintf("%s/%s/%s", c.Namespace, c.Name, c.Schedule)
+}
+
+// missedRuns returns the times schedule was due after last and up to now,
+// which are no older than deadline
+func missedRuns(schedule cron.Schedule, last, now time.Time, deadline time.Duration) []time.Time {
+	earliest := now.Add(-deadline)
+
+	from := last
+	if earliest.After(from) {
+		// Next is at least a second after its argument
+		from = earliest.Add(-time.Second)
+	}
+
+	missed := []time.Time{}
+	for t := schedule.Next(from); !t.IsZero() && !t.After(now); t = schedule.Next(t) {
+		if t.After(last) && !t.Before(earliest) {
+			missed = append(missed, t)
+		}
+	}
+
+	return missed
+}
+
+// catchUp runs job for the runs it missed since it last ran, as per the
+// starting deadline and catch-up policy of its function. A function
+// which has never run is recorded as running now, so that runs missed
+// from here on can be found.
+func (s *Scheduler) catchUp(job *cronJob, schedule cron.Schedule) {
+	if s.state == nil {
+		return
+	}
+
+	c := job.spec()
+	if c.StartingDeadline <= 0 {
+		return
+	}
+
+	now := time.Now()
+
+	last, ok := s.state.LastRun(c.stateKey())
+	if !ok {
+		s.state.Record(c.stateKey(), now)
+		return
+	}
+
+	missed := missedRuns(schedule, last, now, c.StartingDeadline)
+	if len(missed) == 0 {
+		return
+	}
+
+	if c.CatchupPolicy != CatchupAll {
+		missed = missed[len(missed)-1:]
+	} else if len(missed) > maxCatchupRuns {
+		missed = missed[len(missed)-maxCatchupRuns:]
+	}
+
+	log.Printf("Catching up: %s [%s] %d missed run(s) since %s", c.String(), c.ScheduleString(), len(missed), last.Format(time.RFC3339))
+
+	go func() {
+		for _, scheduled := range missed {
+			job.run(scheduled)
+		}
+	}()
+}
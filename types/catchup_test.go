@@ -0,0 +1,196 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestMissedRuns(t *testing.T) {
+	hourly, err := standardParser.Parse("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 6, 1, 13, 30, 0, 0, time.UTC)
+
+	testcases := []struct {
+		Name     string
+		Deadline time.Duration
+		Want     []string
+	}{
+		{Name: "within deadline", Deadline: 2 * time.Hour, Want: []string{"12:00", "13:00"}},
+		{Name: "deadline longer than downtime", Deadline: 24 * time.Hour, Want: []string{"11:00", "12:00", "13:00"}},
+		{Name: "deadline shorter than the last miss", Deadline: 10 * time.Minute, Want: []string{}},
+		{Name: "deadline on a slot", Deadline: 90 * time.Minute, Want: []string{"12:00", "13:00"}},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			got := missedRuns(hourly, last, now, tc.Deadline)
+			if len(got) != len(tc.Want) {
+				t.Fatalf("expected %d missed runs, got: %v", len(tc.Want), got)
+			}
+			for i := range got {
+				if got[i].Format("15:04") != tc.Want[i] {
+					t.Errorf("run %d expected: %s, got: %s", i, tc.Want[i], got[i].Format("15:04"))
+				}
+			}
+		})
+	}
+}
+
+func TestRunState_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "last_runs.json")
+
+	state, err := NewRunState(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	at := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	state.Record("openfaas-fn/nightly/0 2 * * *", at)
+	state.Record("openfaas-fn/nightly/0 2 * * *", at.Add(-time.Hour))
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected changes to be batched before they are saved, got: %v", err)
+	}
+	if err := state.Flush(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	reloaded, err := NewRunState(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	last, ok := reloaded.LastRun("openfaas-fn/nightly/0 2 * * *")
+	if !ok || !last.Equal(at) {
+		t.Errorf("expected: %s, got: %s", at, last)
+	}
+
+	reloaded.Remove("openfaas-fn/nightly/0 2 * * *")
+	if err := reloaded.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	reloaded, _ = NewRunState(path)
+	if _, ok := reloaded.LastRun("openfaas-fn/nightly/0 2 * * *"); ok {
+		t.Error("expected the function to be removed")
+	}
+}
+
+func TestRunState_FlushesInBackground(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "last_runs.json")
+
+	state, err := NewRunState(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	state.Record("openfaas-fn/nightly/0 2 * * *", time.Now())
+
+	for deadline := time.Now().Add(3 * runStateFlushDelay); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		if _, err := os.Stat(path); err == nil {
+			return
+		}
+	}
+
+	t.Fatal("expected the run state to be saved without a call to Flush")
+}
+
+func TestScheduler_CatchUp(t *testing.T) {
+	testcases := []struct {
+		Name        string
+		Annotations map[string]string
+		Want        int
+	}{
+		{Name: "no starting deadline", Annotations: map[string]string{}, Want: 0},
+		{Name: "run once", Annotations: map[string]string{"starting_deadline": "150m"}, Want: 1},
+		{Name: "run each", Annotations: map[string]string{"starting_deadline": "150m", "catchup_policy": "all"}, Want: 2},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var mu sync.Mutex
+			scheduled := []string{}
+			invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				scheduled = append(scheduled, r.Header.Get(ScheduledTimeHeader))
+				mu.Unlock()
+			}, 10)
+
+			cfs := testCronFunctions(t, false, Defaults{}, map[string]string{"schedule": "0 * * * *"}, tc.Annotations)
+
+			state, _ := NewRunState(filepath.Join(t.TempDir(), "last_runs.json"))
+			last := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+			state.Record(cfs[0].stateKey(), last)
+
+			s := NewScheduler(WithRunState(state))
+			if _, err := s.AddCronFunction(cfs[0], invoker); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			time.Sleep(100 * time.Millisecond)
+			for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+				mu.Lock()
+				n := len(scheduled)
+				mu.Unlock()
+				if n >= tc.Want {
+					break
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(scheduled) != tc.Want {
+				t.Fatalf("expected %d runs, got: %v", tc.Want, scheduled)
+			}
+
+			latest := last.Add(2 * time.Hour).UTC().Format(time.RFC3339)
+			if tc.Want > 0 && scheduled[len(scheduled)-1] != latest {
+				t.Errorf("expected the last run to be for %s, got: %s", latest, scheduled[len(scheduled)-1])
+			}
+
+			if recorded, _ := state.LastRun(cfs[0].stateKey()); tc.Want > 0 && recorded.Before(last.Add(2*time.Hour)) {
+				t.Errorf("expected the caught up run to be recorded, got: %s", recorded)
+			}
+		})
+	}
+}
+
+func TestCronJob_RecordsOnlyWithStartingDeadline(t *testing.T) {
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {}, 2)
+
+	state, _ := NewRunState(filepath.Join(t.TempDir(), "last_runs.json"))
+	s := NewScheduler(WithRunState(state))
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+		Name:      "nightly",
+		Namespace: "openfaas-fn",
+		Schedule:  "0 2 * * *",
+	}
+
+	scheduled := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+	(&cronJob{function: c, invoker: invoker, scheduler: s}).run(scheduled)
+	if _, ok := state.LastRun(c.stateKey()); ok {
+		t.Error("expected no run state for a function without a starting deadline")
+	}
+
+	c.StartingDeadline = time.Hour
+	(&cronJob{function: c, invoker: invoker, scheduler: s}).run(scheduled)
+	if last, ok := state.LastRun(c.stateKey()); !ok || !last.Equal(scheduled) {
+		t.Errorf("expected the run to be recorded, got: %s", last)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/openfaas/connector-sdk/types"
+)
+
+// ConcurrencyPolicy decides what happens when a run of a function is due
+// while a previous run is still in flight, as per a Kubernetes CronJob
+type ConcurrencyPolicy string
+
+const (
+	// AllowConcurrent starts the new run alongside the previous one
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+
+	// ForbidConcurrent skips the new run
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+
+	// ReplaceConcurrent cancels the previous run and starts the new one
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+)
+
+var (
+	// ErrRunSkipped is reported for runs which did not start because
+	// of the Forbid concurrency policy
+	ErrRunSkipped = errors.New("run skipped")
+
+	// ErrRunReplaced is reported for runs which were cancelled
+	// because of the Replace concurrency policy
+	ErrRunReplaced = errors.New("run replaced")
+)
+
+// ParseConcurrencyPolicy returns the ConcurrencyPolicy named by value, an
+// empty value selects AllowConcurrent
+func ParseConcurrencyPolicy(value string) (ConcurrencyPolicy, error) {
+	for _, policy := range []ConcurrencyPolicy{AllowConcurrent, ForbidConcurrent, ReplaceConcurrent} {
+		if strings.EqualFold(value, string(policy)) {
+			return policy, nil
+		}
+	}
+
+	if len(value) == 0 {
+		return AllowConcurrent, nil
+	}
+
+	return "", fmt.Errorf("unknown concurrency policy: %q, use %q, %q or %q",
+		value, AllowConcurrent, ForbidConcurrent, ReplaceConcurrent)
+}
+
+// activeRun is a run which is in flight
+type activeRun struct {
+	function string
+	run      Run
+	cancel   context.CancelCauseFunc
+}
+
+// startRun records run as in flight, applying the concurrency policy of c.
+// The returned function must be called when the run has finished. If the
+// run must not start, or the scheduler is stopping, false is returned.
+func (s *Scheduler) startRun(c CronFunction, run Run) (context.Context, func(), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopping {
+		return nil, nil, false
+	}
+
+	key := c.String()
+
+	for _, active := range s.active {
+		if active.function != key {
+			continue
+		}
+
+		switch c.ConcurrencyPolicy {
+		case ForbidConcurrent:
+			return nil, nil, false
+		case ReplaceConcurrent:
+			log.Printf("Replacing: %s run %s", key, active.run.ID)
+			active.cancel(ErrRunReplaced)
+		}
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	s.active[run.ID] = &activeRun{
+		function: key,
+		run:      run,
+		cancel:   cancel,
+	}
+
+	return ctx, func() {
+		s.mu.Lock()
+		delete(s.active, run.ID)
+		s.mu.Unlock()
+
+		cancel(nil)
+	}, true
+}
+
+// reportSkipped sends a response for a run which did not start
+func (c *CronFunction) reportSkipped(i *types.Invoker, run Run) {
+	c.reportNotStarted(i, run, fmt.Errorf("%w: %s is still running", ErrRunSkipped, c.String()), http.StatusConflict)
+}
+
+// reportNotStarted sends a response with err and status for a run which
+// did not start
+func (c *CronFunction) reportNotStarted(i *types.Invoker, run Run, err error, status int) {
+	run.Function, run.Namespace = c.Name, c.Namespace
+
+	i.Responses <- types.InvokerResponse{
+		Context:  WithRun(context.Background(), run),
+		Error:    err,
+		Function: c.Name,
+		Topic:    c.topic(),
+		Status:   status,
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestParseConcurrencyPolicy(t *testing.T) {
+	testcases := []struct {
+		Value   string
+		Want    ConcurrencyPolicy
+		WantErr bool
+	}{
+		{Value: "", Want: AllowConcurrent},
+		{Value: "Allow", Want: AllowConcurrent},
+		{Value: "forbid", Want: ForbidConcurrent},
+		{Value: "Replace", Want: ReplaceConcurrent},
+		{Value: "Queue", WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		got, err := ParseConcurrencyPolicy(tc.Value)
+		if tc.WantErr {
+			if err == nil {
+				t.Errorf("%q expected an error", tc.Value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q unexpected error: %s", tc.Value, err)
+		}
+		if got != tc.Want {
+			t.Errorf("%q expected: %s, got: %s", tc.Value, tc.Want, got)
+		}
+	}
+}
+
+func TestStartRun_Allow(t *testing.T) {
+	s := NewScheduler()
+	c := CronFunction{Name: "job", Namespace: "openfaas-fn", ConcurrencyPolicy: AllowConcurrent}
+
+	_, done1, ok := s.startRun(c, NewRun(time.Now()))
+	if !ok {
+		t.Fatal("expected the first run to start")
+	}
+	defer done1()
+
+	_, done2, ok := s.startRun(c, NewRun(time.Now()))
+	if !ok {
+		t.Fatal("expected the second run to start alongside the first")
+	}
+	defer done2()
+}
+
+func TestStartRun_Forbid(t *testing.T) {
+	s := NewScheduler()
+	c := CronFunction{Name: "job", Namespace: "openfaas-fn", ConcurrencyPolicy: ForbidConcurrent}
+	other := CronFunction{Name: "other", Namespace: "openfaas-fn", ConcurrencyPolicy: ForbidConcurrent}
+
+	_, done, ok := s.startRun(c, NewRun(time.Now()))
+	if !ok {
+		t.Fatal("expected the first run to start")
+	}
+
+	if _, _, ok := s.startRun(c, NewRun(time.Now())); ok {
+		t.Fatal("expected the second run to be skipped")
+	}
+
+	_, doneOther, ok := s.startRun(other, NewRun(time.Now()))
+	if !ok {
+		t.Fatal("expected a run of another function to start")
+	}
+	doneOther()
+
+	done()
+
+	_, done, ok = s.startRun(c, NewRun(time.Now()))
+	if !ok {
+		t.Fatal("expected a run to start once the previous one finished")
+	}
+	done()
+}
+
+func TestStartRun_Replace(t *testing.T) {
+	s := NewScheduler()
+	c := CronFunction{Name: "job", Namespace: "openfaas-fn", ConcurrencyPolicy: ReplaceConcurrent}
+
+	ctx1, done1, ok := s.startRun(c, NewRun(time.Now()))
+	if !ok {
+		t.Fatal("expected the first run to start")
+	}
+	defer done1()
+
+	ctx2, done2, ok := s.startRun(c, NewRun(time.Now()))
+	if !ok {
+		t.Fatal("expected the second run to start")
+	}
+	defer done2()
+
+	if !errors.Is(context.Cause(ctx1), ErrRunReplaced) {
+		t.Errorf("expected the first run to be replaced, got: %v", context.Cause(ctx1))
+	}
+	if ctx2.Err() != nil {
+		t.Errorf("expected the second run to be active, got: %s", ctx2.Err())
+	}
+}
+
+func TestInvokeFunction_Replaced(t *testing.T) {
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, 1)
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:          ptypes.FunctionStatus{Annotations: &annotations},
+		Name:              "slow",
+		Namespace:         "openfaas-fn",
+		ConcurrencyPolicy: ReplaceConcurrent,
+	}
+
+	s := NewScheduler()
+	first := NewRun(time.Now())
+	ctx, done, _ := s.startRun(c, first)
+	defer done()
+
+	go c.InvokeFunction(ctx, invoker, first)
+
+	time.Sleep(50 * time.Millisecond)
+	_, done2, _ := s.startRun(c, NewRun(time.Now()))
+	defer done2()
+
+	select {
+	case res := <-invoker.Responses:
+		if !errors.Is(res.Error, ErrRunReplaced) {
+			t.Errorf("expected the error to be %s, got: %v", ErrRunReplaced, res.Error)
+		}
+		if run, ok := RunFromContext(res.Context); !ok || run.ID != first.ID {
+			t.Errorf("expected the response to carry run %s", first.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the replaced run to be reported")
+	}
+}
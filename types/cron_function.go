@@ -4,10 +4,17 @@
 package types
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
 	"time"
 
 	"github.com/openfaas/connector-sdk/types"
@@ -19,7 +26,76 @@ type CronFunction struct {
 	FuncData  ptypes.FunctionStatus
 	Name      string
 	Namespace string
-	Schedule  string
+
+	// Schedule is a single cron expression, a function with several
+	// expressions in its schedule annotation has one CronFunction each
+	Schedule string
+
+	// Timezone is the IANA time zone the schedule is evaluated in,
+	// when empty the time zone of the connector is used
+	Timezone string
+
+	// ScheduleFormat is the cron syntax the schedule is written in
+	ScheduleFormat ScheduleFormat
+
+	// Payload is sent as the body of each request, when nil
+	// no body is sent
+	Payload []byte
+
+	// PayloadTemplate is rendered for each run to give the body of
+	// the request, it takes precedence over Payload
+	PayloadTemplate *template.Template
+
+	// ContentType overrides the connector's content type
+	ContentType string
+
+	// Headers are added to each request
+	Headers http.Header
+
+	// SecretHeaders maps header names to the files which hold
+	// their values
+	SecretHeaders map[string]string
+
+	// Method is the HTTP method used to invoke the function
+	Method string
+
+	// Path is appended to the URL of the function
+	Path string
+
+	// Query is added to the URL of the function
+	Query url.Values
+
+	// ConcurrencyPolicy decides what happens when a run is due while
+	// the previous run of the function is still in flight
+	ConcurrencyPolicy ConcurrencyPolicy
+
+	// Retry decides if and when failed invocations are tried again
+	Retry RetryPolicy
+
+	// Timeout limits each attempt to invoke the function, when zero
+	// only the timeout of the invoker's HTTP client applies
+	Timeout time.Duration
+
+	// Jitter is the window within which each run is delayed, so that
+	// functions with the same schedule do not all start at once
+	Jitter time.Duration
+
+	// JitterMode decides how the delay within Jitter is chosen
+	JitterMode JitterMode
+
+	// StartingDeadline is how late a run missed while the connector was
+	// down can be started, when zero missed runs are not made up for
+	StartingDeadline time.Duration
+
+	// CatchupPolicy decides how missed runs are made up for
+	CatchupPolicy CatchupPolicy
+
+	// Suspended functions stay scheduled, but their runs are skipped
+	Suspended bool
+
+	// SpecHash changes whenever the function's image or any of the
+	// annotations read by the connector do
+	SpecHash string
 }
 
 func (c *CronFunction) String() string {
@@ -30,69 +106,398 @@ func (c *CronFunction) String() string {
 	return c.Name
 }
 
+// ScheduleString returns the schedule along with the expression its H
+// tokens resolve to and its timezone, if set
+func (c *CronFunction) ScheduleString() string {
+	schedule := c.Schedule
+	if resolved, err := c.ResolvedSchedule(); err == nil && resolved != c.Schedule {
+		schedule = fmt.Sprintf("%s => %s", c.Schedule, resolved)
+	}
+
+	if len(c.Timezone) > 0 {
+		return fmt.Sprintf("%s %s", schedule, c.Timezone)
+	}
+
+	return schedule
+}
+
+// ResolvedSchedule returns the schedule with its H tokens replaced by
+// values derived from the function's namespace and name
+func (c *CronFunction) ResolvedSchedule() (string, error) {
+	return ResolveHashedSchedule(c.Schedule, c.ScheduleFormat, c.Namespace+"/"+c.Name)
+}
+
 // CronFunctions a list of CronFunction
 type CronFunctions []CronFunction
 
-// Contains returns true if the provided CronFunction object is in list
+// SameSchedule returns true if other is the same schedule expression of
+// the same function, though its other settings may differ
+func (c *CronFunction) SameSchedule(other *CronFunction) bool {
+	return c.Name == other.Name &&
+		c.Namespace == other.Namespace &&
+		c.Schedule == other.Schedule
+}
+
+// Contains returns true if the provided CronFunction object is in list,
+// with the same spec
 func (c *CronFunctions) Contains(cf *CronFunction) bool {
 	for _, f := range *c {
-		if f.Name == cf.Name &&
-			f.Namespace == cf.Namespace &&
-			f.Schedule == cf.Schedule {
+		if f.SameSchedule(cf) && f.SpecHash == cf.SpecHash {
 			return true
 		}
 	}
 	return false
 }
 
+// Find returns the CronFunction in list for the same schedule of the
+// same function as cf
+func (c *CronFunctions) Find(cf *CronFunction) (CronFunction, bool) {
+	for _, f := range *c {
+		if f.SameSchedule(cf) {
+			return f, true
+		}
+	}
+	return CronFunction{}, false
+}
+
+func (c *CronFunction) topic() string {
+	if c.FuncData.Annotations == nil {
+		return ""
+	}
+
+	return (*c.FuncData.Annotations)["topic"]
+}
+
 // ToCronFunction converts a ptypes.FunctionStatus object to the CronFunction
-// and returns error if it is not possible
+// and returns error if it is not possible, or if it has several schedules.
+// Settings which are not given as annotations take their built-in defaults.
 func ToCronFunction(f ptypes.FunctionStatus, namespace string, topic string) (CronFunction, error) {
+	cfs, err := ToCronFunctions(f, namespace, topic, Defaults{})
+	if err != nil {
+		return CronFunction{}, err
+	}
+
+	if len(cfs) > 1 {
+		return CronFunction{}, fmt.Errorf("%s has %d schedules, use ToCronFunctions", f.Name, len(cfs))
+	}
+
+	return cfs[0], nil
+}
+
+// ToCronFunctions converts a ptypes.FunctionStatus object to one CronFunction
+// for each expression in its schedule and returns error if it is not possible.
+// Settings which are not given as annotations are taken from defaults.
+func ToCronFunctions(f ptypes.FunctionStatus, namespace string, topic string, defaults Defaults) (CronFunctions, error) {
 	if f.Annotations == nil {
-		return CronFunction{}, fmt.Errorf("%s has no annotations", f.Name)
+		return nil, fmt.Errorf("%s has no annotations", f.Name)
 	}
 
 	fTopic := (*f.Annotations)["topic"]
 	fSchedule := (*f.Annotations)["schedule"]
+	fTimezone := (*f.Annotations)["schedule_timezone"]
+
+	fFormat := defaults.ScheduleFormat
+	if len(fFormat) == 0 {
+		fFormat = StandardFormat
+	}
+	if v, ok := (*f.Annotations)["schedule_format"]; ok {
+		format, err := ParseScheduleFormat(v)
+		if err != nil {
+			return nil, fmt.Errorf("%s has wrong schedule format: %w", f.Name, err)
+		}
+		fFormat = format
+	}
 
 	if fTopic != topic {
-		return CronFunction{}, fmt.Errorf("%s has wrong topic: %s", fTopic, f.Name)
+		return nil, fmt.Errorf("%s has wrong topic: %s", fTopic, f.Name)
+	}
+
+	schedules := SplitSchedule(fSchedule)
+	if len(schedules) == 0 {
+		return nil, fmt.Errorf("%s has wrong cron schedule: %s", f.Name, fSchedule)
+	}
+
+	for _, schedule := range schedules {
+		if !CheckScheduleFormat(schedule, fFormat) {
+			return nil, fmt.Errorf("%s has wrong cron schedule: %s", f.Name, schedule)
+		}
+	}
+
+	if len(fTimezone) > 0 {
+		if _, err := LoadTimezone(fTimezone); err != nil {
+			return nil, fmt.Errorf("%s has wrong schedule timezone: %s", f.Name, fTimezone)
+		}
+
+		for _, schedule := range schedules {
+			if hasTimezonePrefix(schedule) {
+				return nil, fmt.Errorf("%s sets a timezone in both its schedule and schedule_timezone", f.Name)
+			}
+		}
+	}
+
+	fPayload, err := readPayload(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong payload: %w", f.Name, err)
+	}
+
+	fPayloadTemplate, err := readPayloadTemplate(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong payload template: %w", f.Name, err)
+	}
+
+	fContentType, err := readContentType(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong content type: %w", f.Name, err)
+	}
+
+	fHeaders, err := readHeaders(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong headers: %w", f.Name, err)
+	}
+
+	fSecretHeaders, err := readSecretHeaders(*f.Annotations, defaults.HeaderSecretsPath, namespace)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong secret headers: %w", f.Name, err)
+	}
+
+	for name := range fSecretHeaders {
+		if _, ok := fHeaders[name]; ok {
+			return nil, fmt.Errorf("%s sets header %s in both headers and secret_headers", f.Name, name)
+		}
+	}
+
+	fMethod, err := readMethod(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong method: %w", f.Name, err)
+	}
+
+	if (fMethod == http.MethodGet || fMethod == http.MethodHead) && (fPayload != nil || fPayloadTemplate != nil) {
+		return nil, fmt.Errorf("%s cannot send a payload with %s", f.Name, fMethod)
+	}
+
+	fPath, err := readPath(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong path: %w", f.Name, err)
 	}
 
-	if !CheckSchedule(fSchedule) {
-		return CronFunction{}, fmt.Errorf("%s has wrong cron schedule: %s", f.Name, fSchedule)
+	fQuery, err := readQuery(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong query: %w", f.Name, err)
+	}
+
+	fConcurrencyPolicy, err := ParseConcurrencyPolicy((*f.Annotations)["concurrency_policy"])
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong concurrency policy: %w", f.Name, err)
+	}
+
+	fRetry, err := readRetryPolicy(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong retry policy: %w", f.Name, err)
+	}
+
+	fTimeout := defaults.Timeout
+	if v, ok := (*f.Annotations)["timeout"]; ok {
+		timeout, err := time.ParseDuration(v)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("%s has wrong timeout: %q", f.Name, v)
+		}
+		fTimeout = timeout
+	}
+
+	fJitter, fJitterMode, err := readJitter(*f.Annotations, defaults)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong jitter: %w", f.Name, err)
+	}
+
+	fStartingDeadline, fCatchupPolicy, err := readCatchup(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong catch-up settings: %w", f.Name, err)
+	}
+
+	fSuspended, err := readSuspend(*f.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("%s has wrong suspend: %w", f.Name, err)
+	}
+
+	fSpecHash := SpecHash(f)
+
+	cronFunctions := make(CronFunctions, 0, len(schedules))
+	for _, schedule := range schedules {
+		cronFunctions = append(cronFunctions, CronFunction{
+			FuncData:          f,
+			Name:              f.Name,
+			Namespace:         namespace,
+			Schedule:          schedule,
+			Timezone:          fTimezone,
+			ScheduleFormat:    fFormat,
+			Payload:           fPayload,
+			PayloadTemplate:   fPayloadTemplate,
+			ContentType:       fContentType,
+			Headers:           fHeaders,
+			SecretHeaders:     fSecretHeaders,
+			Method:            fMethod,
+			Path:              fPath,
+			Query:             fQuery,
+			ConcurrencyPolicy: fConcurrencyPolicy,
+			Retry:             fRetry,
+			Timeout:           fTimeout,
+			Jitter:            fJitter,
+			JitterMode:        fJitterMode,
+			StartingDeadline:  fStartingDeadline,
+			CatchupPolicy:     fCatchupPolicy,
+			Suspended:         fSuspended,
+			SpecHash:          fSpecHash,
+		})
 	}
 
-	return CronFunction{
-		FuncData:  f,
-		Name:      f.Name,
-		Namespace: namespace,
-		Schedule:  fSchedule,
-	}, nil
+	return cronFunctions, nil
 }
 
-// InvokeFunction Invokes the cron function
-func (c CronFunction) InvokeFunction(i *types.Invoker) (*[]byte, error) {
+// SplitSchedule returns each cron expression in a schedule annotation.
+// Expressions are separated by semicolons or new lines, blank and
+// repeated expressions are dropped.
+func SplitSchedule(schedule string) []string {
+	fields := strings.FieldsFunc(schedule, func(r rune) bool {
+		return r == ';' || r == '\n'
+	})
+
+	schedules := []string{}
+	for _, field := range fields {
+		expression := strings.Join(strings.Fields(field), " ")
+		if len(expression) == 0 || slices.Contains(schedules, expression) {
+			continue
+		}
+		schedules = append(schedules, expression)
+	}
+
+	return schedules
+}
+
+// InvokeFunction Invokes the cron function for the given run. The request is
+// cancelled along with ctx, and responses carry the run in their Context.
+// Failed attempts are retried as per the function's RetryPolicy, and a
+// response is sent for each attempt.
+func (c CronFunction) InvokeFunction(ctx context.Context, i *types.Invoker, run Run) (*[]byte, error) {
+
+	name := c.Name
+	topic := c.topic()
+	run.Function, run.Namespace = c.Name, c.Namespace
+	run.Attempt = 1
+	ctx = WithRun(ctx, run)
+
+	headers, err := c.customHeaders()
+	if err != nil {
+		i.Responses <- types.InvokerResponse{
+			Context:  ctx,
+			Error:    fmt.Errorf("unable to invoke %s %w", c.String(), err),
+			Function: name,
+			Topic:    topic,
+			Status:   http.StatusInternalServerError,
+		}
+		return nil, err
+	}
+
+	payload, err := c.renderPayload(run)
+	if err != nil {
+		i.Responses <- types.InvokerResponse{
+			Context:  ctx,
+			Error:    fmt.Errorf("unable to invoke %s %w", c.String(), err),
+			Function: name,
+			Topic:    topic,
+			Status:   http.StatusInternalServerError,
+		}
+		return nil, err
+	}
+
+	retry := c.Retry
+	if retry.MaxAttempts < 1 {
+		retry.MaxAttempts = 1
+	}
+
+	for {
+		body, res, err := c.invokeOnce(ctx, i, run, headers, payload)
+
+		status := 0
+		var resHeader http.Header
+		if res != nil {
+			status, resHeader = res.StatusCode, res.Header
+		}
+
+		if run.Attempt >= retry.MaxAttempts || ctx.Err() != nil || !retry.retryable(status, err) {
+			return body, err
+		}
+
+		wait := retry.wait(run.Attempt, resHeader)
+		log.Printf("Retrying: %s attempt %d/%d failed, next attempt in %s", c.String(), run.Attempt, retry.MaxAttempts, wait)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			err := fmt.Errorf("retry of %s cancelled %w", c.String(), context.Cause(ctx))
+
+			run.Attempt++
+			c.reportNotStarted(i, run, err, errorStatus(err))
+			return body, err
+		}
 
+		run.Attempt++
+		ctx = WithRun(ctx, run)
+	}
+}
+
+// invokeOnce makes a single attempt to invoke the function, and sends
+// the outcome to the invoker's Responses channel
+func (c *CronFunction) invokeOnce(ctx context.Context, i *types.Invoker, run Run, headers http.Header, payload []byte) (*[]byte, *http.Response, error) {
 	name := c.Name
-	topic := (*c.FuncData.Annotations)["topic"]
+	topic := c.topic()
+
+	reqCtx := ctx
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		reqCtx, cancel = context.WithTimeoutCause(ctx, c.Timeout, ErrInvocationTimeout)
+		defer cancel()
+	}
+
+	contentType := i.ContentType
+	if len(c.ContentType) > 0 {
+		contentType = c.ContentType
+	}
 
-	headers := http.Header{
-		"X-Topic":     {topic},
-		"X-Connector": {"cron-connector"},
+	gwURL := c.requestURL(i.GatewayURL)
+
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
 	}
 
-	gwURL := fmt.Sprintf("%s/%s", i.GatewayURL, c.String())
+	method := c.Method
+	if len(method) == 0 {
+		method = http.MethodPost
+	}
 
-	req, err := http.NewRequest(http.MethodPost, gwURL, nil)
+	req, err := http.NewRequestWithContext(reqCtx, method, gwURL, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create http request to %s %w", gwURL, err)
+		err = fmt.Errorf("%w to %s %w", ErrInvalidRequest, gwURL, err)
+
+		i.Responses <- types.InvokerResponse{
+			Context:  ctx,
+			Error:    fmt.Errorf("unable to invoke %s %w", c.String(), err),
+			Function: name,
+			Topic:    topic,
+			Status:   http.StatusInternalServerError,
+		}
+		return nil, nil, err
 	}
 
 	for k, v := range headers {
 		req.Header[k] = v
 	}
 
+	req.Header.Set("X-Topic", topic)
+	req.Header.Set("X-Connector", "cron-connector")
+	req.Header.Set("Content-Type", contentType)
+	c.setRunHeaders(req.Header, run)
+
 	if req.Body != nil {
 		defer req.Body.Close()
 	}
@@ -101,37 +506,44 @@ func (c CronFunction) InvokeFunction(i *types.Invoker) (*[]byte, error) {
 	var body *[]byte
 	res, err := i.Client.Do(req)
 	if err != nil {
+		err = withCancelCause(reqCtx, err)
+
 		i.Responses <- types.InvokerResponse{
+			Context:  ctx,
 			Error:    fmt.Errorf("unable to invoke %s %w", c.String(), err),
 			Function: name,
 			Topic:    topic,
-			Status:   http.StatusServiceUnavailable,
+			Status:   errorStatus(err),
 			Duration: time.Since(start),
 		}
-		return nil, err
+		return nil, nil, err
 	}
 
 	if res.Body != nil {
 		defer res.Body.Close()
-		bytesOut, err := ioutil.ReadAll(res.Body)
+		bytesOut, err := io.ReadAll(res.Body)
 
 		if err != nil {
+			err = withCancelCause(reqCtx, err)
+
 			log.Printf("Error reading body")
 			i.Responses <- types.InvokerResponse{
+				Context:  ctx,
 				Error:    fmt.Errorf("unable to invoke %s %w", c.String(), err),
-				Status:   http.StatusServiceUnavailable,
+				Status:   errorStatus(err),
 				Function: name,
 				Topic:    topic,
 				Duration: time.Since(start),
 			}
 
-			return nil, fmt.Errorf("unable to read body %s", err)
+			return nil, nil, fmt.Errorf("unable to read body %w", err)
 		}
 
 		body = &bytesOut
 	}
 
 	i.Responses <- types.InvokerResponse{
+		Context:  ctx,
 		Body:     body,
 		Status:   res.StatusCode,
 		Header:   &res.Header,
@@ -140,5 +552,33 @@ func (c CronFunction) InvokeFunction(i *types.Invoker) (*[]byte, error) {
 		Duration: time.Since(start),
 	}
 
-	return body, nil
+	return body, res, nil
+}
+
+// ErrInvocationTimeout is reported for attempts which did not complete
+// within the function's timeout
+var ErrInvocationTimeout = errors.New("invocation timed out")
+
+// ErrInvalidRequest is reported for attempts for which no request could
+// be made, these are not retried
+var ErrInvalidRequest = errors.New("failed to create http request")
+
+// withCancelCause adds the reason that ctx was cancelled to err, such as
+// the run being replaced or timing out
+func withCancelCause(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); cause != nil && !errors.Is(err, cause) {
+		return fmt.Errorf("%w: %w", cause, err)
+	}
+
+	return err
+}
+
+// errorStatus returns the status reported for an attempt which failed
+// without a response from the gateway
+func errorStatus(err error) int {
+	if errors.Is(err, ErrInvocationTimeout) {
+		return http.StatusGatewayTimeout
+	}
+
+	return http.StatusServiceUnavailable
 }
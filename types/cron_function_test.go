@@ -0,0 +1,221 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestSplitSchedule(t *testing.T) {
+	testcases := []struct {
+		Schedule string
+		Want     []string
+	}{
+		{Schedule: "*/5 * * * *", Want: []string{"*/5 * * * *"}},
+		{Schedule: "0 9 * * 1-5; 0 12 * * 0,6", Want: []string{"0 9 * * 1-5", "0 12 * * 0,6"}},
+		{Schedule: "0 9 * * 1-5\n0 12 * * 0,6\n", Want: []string{"0 9 * * 1-5", "0 12 * * 0,6"}},
+		{Schedule: "0  9 * * *; 0 9 * * *;;", Want: []string{"0 9 * * *"}},
+		{Schedule: " ; ", Want: []string{}},
+	}
+
+	for _, tc := range testcases {
+		got := SplitSchedule(tc.Schedule)
+		if !slices.Equal(got, tc.Want) {
+			t.Errorf("%q expected: %q, got: %q", tc.Schedule, tc.Want, got)
+		}
+	}
+}
+
+func TestToCronFunctions_MultipleSchedules(t *testing.T) {
+	annotations := map[string]string{
+		"topic":    "cron-function",
+		"schedule": "0 9 * * 1-5; 0 12 * * 0,6",
+	}
+	f := ptypes.FunctionStatus{Name: "report", Annotations: &annotations}
+
+	cfs, err := ToCronFunctions(f, "openfaas-fn", "cron-function", Defaults{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(cfs) != 2 {
+		t.Fatalf("expected 2 functions, got %d", len(cfs))
+	}
+
+	for i, want := range []string{"0 9 * * 1-5", "0 12 * * 0,6"} {
+		if cfs[i].Schedule != want {
+			t.Errorf("expected: %s, got: %s", want, cfs[i].Schedule)
+		}
+		if cfs[i].Name != "report" || cfs[i].Namespace != "openfaas-fn" {
+			t.Errorf("unexpected function: %s", cfs[i].String())
+		}
+	}
+}
+
+func TestToCronFunction(t *testing.T) {
+	testcases := []struct {
+		Name     string
+		Schedule string
+		WantErr  bool
+	}{
+		{Name: "single schedule", Schedule: "0 9 * * 1-5"},
+		{Name: "several schedules", Schedule: "0 9 * * 1-5; 0 12 * * 0,6", WantErr: true},
+		{Name: "invalid schedule", Schedule: "0 25 * * *", WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			annotations := map[string]string{
+				"topic":    "cron-function",
+				"schedule": tc.Schedule,
+			}
+			f := ptypes.FunctionStatus{Name: "report", Annotations: &annotations}
+
+			cf, err := ToCronFunction(f, "openfaas-fn", "cron-function")
+			if tc.WantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if cf.Schedule != tc.Schedule || cf.Name != "report" || cf.Namespace != "openfaas-fn" {
+				t.Errorf("unexpected function: %s [%s]", cf.String(), cf.Schedule)
+			}
+		})
+	}
+}
+
+func TestToCronFunctions_InvalidExpression(t *testing.T) {
+	annotations := map[string]string{
+		"topic":    "cron-function",
+		"schedule": "0 9 * * 1-5; 0 25 * * *",
+	}
+	f := ptypes.FunctionStatus{Name: "report", Annotations: &annotations}
+
+	if _, err := ToCronFunctions(f, "openfaas-fn", "cron-function", Defaults{}); err == nil {
+		t.Error("expected an error when one of the expressions is invalid")
+	}
+}
+
+func TestToCronFunctions_Timeout(t *testing.T) {
+	testcases := []struct {
+		Name       string
+		Annotation string
+		Default    time.Duration
+		Want       time.Duration
+		WantErr    bool
+	}{
+		{Name: "no timeout"},
+		{Name: "connector default", Default: time.Minute, Want: time.Minute},
+		{Name: "annotation overrides default", Annotation: "30s", Default: time.Minute, Want: 30 * time.Second},
+		{Name: "invalid", Annotation: "half a minute", WantErr: true},
+		{Name: "negative", Annotation: "-1s", WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			annotations := map[string]string{}
+			if len(tc.Annotation) > 0 {
+				annotations["timeout"] = tc.Annotation
+			}
+
+			cfs := testCronFunctions(t, tc.WantErr, Defaults{Timeout: tc.Default}, annotations)
+			if tc.WantErr {
+				return
+			}
+
+			if cfs[0].Timeout != tc.Want {
+				t.Errorf("expected: %s, got: %s", tc.Want, cfs[0].Timeout)
+			}
+		})
+	}
+}
+
+func TestInvokeFunction_Timeout(t *testing.T) {
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, 1)
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+		Name:      "slow",
+		Namespace: "openfaas-fn",
+		Timeout:   50 * time.Millisecond,
+	}
+
+	_, err := c.InvokeFunction(context.Background(), invoker, NewRun(time.Now()))
+	if !errors.Is(err, ErrInvocationTimeout) {
+		t.Fatalf("expected error: %s, got: %v", ErrInvocationTimeout, err)
+	}
+
+	res := <-invoker.Responses
+	if res.Status != http.StatusGatewayTimeout {
+		t.Errorf("expected status: %d, got: %d", http.StatusGatewayTimeout, res.Status)
+	}
+	if !errors.Is(res.Error, ErrInvocationTimeout) {
+		t.Errorf("expected error: %s, got: %v", ErrInvocationTimeout, res.Error)
+	}
+}
+
+func TestToCronFunctions_SpecHash(t *testing.T) {
+	base := func() ptypes.FunctionStatus {
+		annotations := map[string]string{
+			"topic":    "cron-function",
+			"schedule": "0 2 * * *",
+			"payload":  `{"report":"daily"}`,
+		}
+		labels := map[string]string{"team": "reports"}
+		return ptypes.FunctionStatus{Name: "report", Image: "reports:1.0", Annotations: &annotations, Labels: &labels}
+	}
+
+	testcases := []struct {
+		Name    string
+		Change  func(f *ptypes.FunctionStatus)
+		Changed bool
+	}{
+		{Name: "unchanged", Change: func(f *ptypes.FunctionStatus) {}, Changed: false},
+		{Name: "image", Change: func(f *ptypes.FunctionStatus) { f.Image = "reports:1.1" }, Changed: true},
+		{Name: "payload", Change: func(f *ptypes.FunctionStatus) { (*f.Annotations)["payload"] = `{"report":"weekly"}` }, Changed: true},
+		{Name: "empty payload", Change: func(f *ptypes.FunctionStatus) { (*f.Annotations)["payload"] = "" }, Changed: true},
+		{Name: "path", Change: func(f *ptypes.FunctionStatus) { (*f.Annotations)["path"] = "/daily" }, Changed: true},
+		{Name: "timeout", Change: func(f *ptypes.FunctionStatus) { (*f.Annotations)["timeout"] = "30s" }, Changed: true},
+		{Name: "another schedule", Change: func(f *ptypes.FunctionStatus) { (*f.Annotations)["schedule"] = "0 2 * * *; 0 14 * * *" }, Changed: false},
+		{Name: "suspend", Change: func(f *ptypes.FunctionStatus) { (*f.Annotations)["suspend"] = "true" }, Changed: true},
+		{Name: "unrelated annotation", Change: func(f *ptypes.FunctionStatus) { (*f.Annotations)["prometheus.io.scrape"] = "false" }, Changed: false},
+		{Name: "label", Change: func(f *ptypes.FunctionStatus) { (*f.Labels)["team"] = "finance" }, Changed: false},
+	}
+
+	before, err := ToCronFunctions(base(), "openfaas-fn", "cron-function", Defaults{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			f := base()
+			tc.Change(&f)
+
+			after, err := ToCronFunctions(f, "openfaas-fn", "cron-function", Defaults{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if changed := after[0].SpecHash != before[0].SpecHash; changed != tc.Changed {
+				t.Errorf("expected changed: %v, got: %v", tc.Changed, changed)
+			}
+		})
+	}
+}
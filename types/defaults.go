@@ -0,0 +1,31 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import "time"
+
+// Defaults are connector-wide settings which apply to functions that
+// do not override them with an annotation
+type Defaults struct {
+	// ScheduleFormat is used to parse the schedule of functions which
+	// do not have a schedule_format annotation
+	ScheduleFormat ScheduleFormat
+
+	// HeaderSecretsPath is the directory that the secret_headers
+	// annotation reads its values from, with a sub-directory for each
+	// namespace. When empty, secret_headers cannot be used.
+	HeaderSecretsPath string
+
+	// Timeout limits each invocation of functions which do not have
+	// a timeout annotation, zero means no limit
+	Timeout time.Duration
+
+	// Jitter is the window runs are delayed within, for functions
+	// which do not have a jitter annotation
+	Jitter time.Duration
+
+	// JitterMode is used for functions which do not have a
+	// jitter_mode annotation
+	JitterMode JitterMode
+}
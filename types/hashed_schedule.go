@@ -0,0 +1,111 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// hashedToken matches the Jenkins-style H token, with an optional
+// range and step, i.e. H, H(0-5), H/15 or H(0-29)/10
+var hashedToken = regexp.MustCompile(`^H(?:\((\d+)-(\d+)\))?(?:/(\d+))?$`)
+
+// fieldBounds are the values an H token can resolve to in each field,
+// days of the month stop at 28 so that every month has a run
+var fieldBounds = map[string][2]int{
+	"second": {0, 59},
+	"minute": {0, 59},
+	"hour":   {0, 23},
+	"dom":    {1, 28},
+	"month":  {1, 12},
+	"dow":    {0, 6},
+}
+
+// ResolveHashedSchedule replaces each H token in schedule with a value
+// which is derived from seed, such as the function's namespace and name.
+// The same seed always gives the same schedule. Schedules without an
+// H token are returned unchanged.
+func ResolveHashedSchedule(schedule string, format ScheduleFormat, seed string) (string, error) {
+	// The timezone prefix is stripped first, as zone names such as
+	// Asia/Ho_Chi_Minh contain an H
+	prefix := ""
+	if hasTimezonePrefix(schedule) {
+		i := strings.Index(schedule, " ")
+		if i < 0 {
+			return schedule, nil
+		}
+		prefix, schedule = schedule[:i+1], schedule[i+1:]
+	}
+
+	if !strings.Contains(schedule, "H") || strings.HasPrefix(schedule, "@") {
+		return prefix + schedule, nil
+	}
+
+	fields := strings.Fields(schedule)
+
+	names := []string{"minute", "hour", "dom", "month", "dow"}
+	if format == SecondsFormat && len(fields) == 6 {
+		names = append([]string{"second"}, names...)
+	}
+
+	if len(fields) != len(names) {
+		return "", fmt.Errorf("expected %d fields, found %d: %s", len(names), len(fields), schedule)
+	}
+
+	for i, field := range fields {
+		parts := strings.Split(field, ",")
+		for j, part := range parts {
+			if !strings.HasPrefix(part, "H") {
+				continue
+			}
+
+			resolved, err := resolveHashedToken(part, names[i], fmt.Sprintf("%s/%s", seed, names[i]))
+			if err != nil {
+				return "", err
+			}
+			parts[j] = resolved
+		}
+		fields[i] = strings.Join(parts, ",")
+	}
+
+	return prefix + strings.Join(fields, " "), nil
+}
+
+func resolveHashedToken(token, field, seed string) (string, error) {
+	match := hashedToken.FindStringSubmatch(token)
+	if match == nil {
+		return "", fmt.Errorf("invalid H token in %s field: %s", field, token)
+	}
+
+	low, high := fieldBounds[field][0], fieldBounds[field][1]
+
+	if len(match[1]) > 0 {
+		from, _ := strconv.Atoi(match[1])
+		to, _ := strconv.Atoi(match[2])
+		if from > to || from < low || to > high {
+			return "", fmt.Errorf("H range in %s field must be within %d-%d: %s", field, low, high, token)
+		}
+		low, high = from, to
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	hash := h.Sum64()
+
+	if len(match[3]) > 0 {
+		step, _ := strconv.Atoi(match[3])
+		if step < 1 || step > high-low+1 {
+			return "", fmt.Errorf("H step in %s field must be between 1 and %d: %s", field, high-low+1, token)
+		}
+
+		start := low + int(hash%uint64(step))
+		return fmt.Sprintf("%d-%d/%d", start, high, step), nil
+	}
+
+	return strconv.Itoa(low + int(hash%uint64(high-low+1))), nil
+}
@@ -0,0 +1,119 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResolveHashedSchedule_Stable(t *testing.T) {
+	first, err := ResolveHashedSchedule("H H(0-5) * * *", StandardFormat, "openfaas-fn/nightly")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		got, _ := ResolveHashedSchedule("H H(0-5) * * *", StandardFormat, "openfaas-fn/nightly")
+		if got != first {
+			t.Fatalf("expected the same schedule for the same seed, got: %s and %s", first, got)
+		}
+	}
+
+	fields := strings.Fields(first)
+	minute, err := strconv.Atoi(fields[0])
+	if err != nil || minute < 0 || minute > 59 {
+		t.Errorf("expected a minute within 0-59, got: %s", fields[0])
+	}
+	hour, err := strconv.Atoi(fields[1])
+	if err != nil || hour < 0 || hour > 5 {
+		t.Errorf("expected an hour within 0-5, got: %s", fields[1])
+	}
+	if strings.Join(fields[2:], " ") != "* * *" {
+		t.Errorf("expected other fields to be unchanged, got: %s", first)
+	}
+}
+
+func TestResolveHashedSchedule_SpreadsFunctions(t *testing.T) {
+	seen := map[string]bool{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		resolved, err := ResolveHashedSchedule("H * * * *", StandardFormat, "openfaas-fn/"+name)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		seen[resolved] = true
+	}
+
+	if len(seen) < 2 {
+		t.Error("expected functions to resolve to different minutes")
+	}
+}
+
+func TestResolveHashedSchedule(t *testing.T) {
+	testcases := []struct {
+		Schedule string
+		Format   ScheduleFormat
+		Check    func(string) bool
+		WantErr  bool
+	}{
+		{Schedule: "*/5 * * * *", Check: func(s string) bool { return s == "*/5 * * * *" }},
+		{Schedule: "@hourly", Check: func(s string) bool { return s == "@hourly" }},
+		{Schedule: "H/15 * * * *", Check: func(s string) bool { return strings.HasSuffix(strings.Fields(s)[0], "-59/15") }},
+		{Schedule: "0 9 H * *", Check: func(s string) bool { d, _ := strconv.Atoi(strings.Fields(s)[2]); return d >= 1 && d <= 28 }},
+		{Schedule: "0 H(9-17),20 * * *", Check: func(s string) bool { return strings.HasSuffix(strings.Fields(s)[1], ",20") }},
+		{Schedule: "CRON_TZ=Europe/London H 3 * * *", Check: func(s string) bool { return strings.HasPrefix(s, "CRON_TZ=Europe/London ") }},
+		{Schedule: "CRON_TZ=Asia/Ho_Chi_Minh @daily", Check: func(s string) bool { return s == "CRON_TZ=Asia/Ho_Chi_Minh @daily" }},
+		{Schedule: "TZ=Europe/Helsinki @hourly", Check: func(s string) bool { return s == "TZ=Europe/Helsinki @hourly" }},
+		{Schedule: "TZ=Europe/Helsinki 0 9 * * *", Check: func(s string) bool { return s == "TZ=Europe/Helsinki 0 9 * * *" }},
+		{Schedule: "CRON_TZ=Asia/Ho_Chi_Minh H 3 * * *", Check: func(s string) bool {
+			return strings.HasPrefix(s, "CRON_TZ=Asia/Ho_Chi_Minh ") && !strings.Contains(strings.Fields(s)[1], "H")
+		}},
+		{Schedule: "H H * * * *", Format: SecondsFormat, Check: func(s string) bool { return len(strings.Fields(s)) == 6 }},
+		{Schedule: "H(5-0) * * * *", WantErr: true},
+		{Schedule: "H(0-60) * * * *", WantErr: true},
+		{Schedule: "H/0 * * * *", WantErr: true},
+		{Schedule: "HH * * * *", WantErr: true},
+		{Schedule: "H * * *", WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		format := tc.Format
+		if len(format) == 0 {
+			format = StandardFormat
+		}
+
+		got, err := ResolveHashedSchedule(tc.Schedule, format, "openfaas-fn/job")
+		if tc.WantErr {
+			if err == nil {
+				t.Errorf("%q expected an error, got: %s", tc.Schedule, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q unexpected error: %s", tc.Schedule, err)
+			continue
+		}
+		if !tc.Check(got) {
+			t.Errorf("%q resolved to an unexpected schedule: %s", tc.Schedule, got)
+		}
+		if !CheckScheduleFormat(tc.Schedule, format) {
+			t.Errorf("%q expected to be a valid schedule", tc.Schedule)
+		}
+	}
+}
+
+func TestScheduleString_Resolved(t *testing.T) {
+	c := CronFunction{Name: "nightly", Namespace: "openfaas-fn", Schedule: "H H(0-5) * * *", ScheduleFormat: StandardFormat, Timezone: "Europe/London"}
+
+	resolved, err := c.ResolvedSchedule()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := "H H(0-5) * * * => " + resolved + " Europe/London"
+	if got := c.ScheduleString(); got != want {
+		t.Errorf("expected: %s, got: %s", want, got)
+	}
+}
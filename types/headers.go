@@ -0,0 +1,200 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Headers which describe the run to the function
+const (
+	// ScheduledTimeHeader is the time the run was planned for
+	ScheduledTimeHeader = "X-Cron-Scheduled-Time"
+
+	// DispatchTimeHeader is the time the request was sent
+	DispatchTimeHeader = "X-Cron-Dispatch-Time"
+
+	// ScheduleHeader is the cron expression which triggered the run
+	ScheduleHeader = "X-Cron-Schedule"
+
+	// RunIDHeader is unique to each run
+	RunIDHeader = "X-Cron-Run-Id"
+
+	// IdempotencyKeyHeader is the same for every run of a function
+	// which was planned for the same time
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	// TriggerHeader is "manual" for runs triggered on demand, and
+	// "schedule" otherwise
+	TriggerHeader = "X-Cron-Trigger"
+)
+
+// reservedHeaders are set by the connector and cannot be given in
+// the headers or secret_headers annotations
+var reservedHeaders = []string{
+	"X-Topic",
+	"X-Connector",
+	"Content-Type",
+	"Content-Length",
+	"Host",
+	"Transfer-Encoding",
+	ScheduledTimeHeader,
+	DispatchTimeHeader,
+	ScheduleHeader,
+	RunIDHeader,
+	IdempotencyKeyHeader,
+	TriggerHeader,
+}
+
+// readHeaders parses the headers annotation, a JSON map of header
+// names to values
+func readHeaders(annotations map[string]string) (http.Header, error) {
+	values, err := readHeaderMap(annotations, "headers")
+	if err != nil || values == nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	for name, value := range values {
+		headers.Set(name, value)
+	}
+
+	return headers, nil
+}
+
+// readSecretHeaders parses the secret_headers annotation, a JSON map of
+// header names to the names of files which hold their values. Files are
+// read from the namespace's directory under headerSecretsPath, so that
+// functions cannot read the secrets of another namespace. The paths of
+// the files are returned.
+func readSecretHeaders(annotations map[string]string, headerSecretsPath, namespace string) (map[string]string, error) {
+	secrets, err := readHeaderMap(annotations, "secret_headers")
+	if err != nil || secrets == nil {
+		return nil, err
+	}
+
+	if len(headerSecretsPath) == 0 {
+		return nil, fmt.Errorf("secret_headers cannot be used until header_secrets_path is set")
+	}
+
+	paths := make(map[string]string, len(secrets))
+	for name, secret := range secrets {
+		if len(secret) == 0 || secret != path.Base(secret) || secret == "." || secret == ".." {
+			return nil, fmt.Errorf("secret for header %s must be a file name: %q", name, secret)
+		}
+
+		// The gateway's credentials must never be sent to a function,
+		// even when they are mounted in the same directory by mistake
+		if strings.HasPrefix(secret, "basic-auth-") {
+			return nil, fmt.Errorf("secret for header %s cannot be the connector's credentials: %q", name, secret)
+		}
+
+		paths[name] = filepath.Join(headerSecretsPath, namespace, secret)
+	}
+
+	return paths, nil
+}
+
+func readHeaderMap(annotations map[string]string, annotation string) (map[string]string, error) {
+	text, ok := annotations[annotation]
+	if !ok {
+		return nil, nil
+	}
+
+	values := map[string]string{}
+	if err := json.Unmarshal([]byte(text), &values); err != nil {
+		return nil, fmt.Errorf("%s must be a JSON object of strings: %w", annotation, err)
+	}
+
+	canonical := make(map[string]string, len(values))
+	for name, value := range values {
+		if !validHeaderName(name) {
+			return nil, fmt.Errorf("%s has an invalid header name: %q", annotation, name)
+		}
+
+		name = http.CanonicalHeaderKey(name)
+		if isReservedHeader(name) {
+			return nil, fmt.Errorf("%s cannot set %s, which is set by the connector", annotation, name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("%s has an invalid value for %s", annotation, name)
+		}
+
+		canonical[name] = value
+	}
+
+	return canonical, nil
+}
+
+// validHeaderName returns true if name is a valid HTTP token
+func validHeaderName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+
+	for _, r := range name {
+		if r > 127 || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IdempotencyKey returns a key which identifies the run of the function
+// that was planned for scheduled, so that repeated requests for the same
+// run can be detected by the function
+func (c *CronFunction) IdempotencyKey(scheduled time.Time) string {
+	key := fmt.Sprintf("%s/%s/%s", c.Namespace, c.Name, scheduled.UTC().Format(time.RFC3339Nano))
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(key)))
+}
+
+// setRunHeaders adds the headers which describe run to headers
+func (c *CronFunction) setRunHeaders(headers http.Header, run Run) {
+	headers.Set(ScheduledTimeHeader, run.Scheduled.UTC().Format(time.RFC3339))
+	headers.Set(DispatchTimeHeader, time.Now().UTC().Format(time.RFC3339Nano))
+	headers.Set(ScheduleHeader, c.Schedule)
+	headers.Set(RunIDHeader, run.ID)
+	headers.Set(IdempotencyKeyHeader, c.IdempotencyKey(run.Scheduled))
+	headers.Set(TriggerHeader, run.trigger())
+}
+
+func isReservedHeader(name string) bool {
+	return slices.Contains(reservedHeaders, http.CanonicalHeaderKey(name))
+}
+
+// customHeaders returns the headers from the annotations of the function.
+// Secrets are read on each call, so that rotated values are picked up
+// without re-deploying the function.
+func (c *CronFunction) customHeaders() (http.Header, error) {
+	headers := c.Headers.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	for name, secretPath := range c.SecretHeaders {
+		data, err := os.ReadFile(secretPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read secret for header %s: %w", name, err)
+		}
+
+		value := strings.TrimSpace(string(data))
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("secret for header %s has more than one line", name)
+		}
+
+		headers.Set(name, value)
+	}
+
+	return headers, nil
+}
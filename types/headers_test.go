@@ -0,0 +1,179 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openfaas/connector-sdk/types"
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestToCronFunctions_Headers(t *testing.T) {
+	testcases := []struct {
+		Name        string
+		Annotations map[string]string
+		WantErr     bool
+	}{
+		{Name: "no headers", Annotations: map[string]string{}},
+		{Name: "headers", Annotations: map[string]string{"headers": `{"x-mode": "full", "Accept": "application/json"}`}},
+		{Name: "secret headers", Annotations: map[string]string{"secret_headers": `{"Authorization": "cleanup-token"}`}},
+		{Name: "not json", Annotations: map[string]string{"headers": `X-Mode: full`}, WantErr: true},
+		{Name: "not a string", Annotations: map[string]string{"headers": `{"X-Retries": 3}`}, WantErr: true},
+		{Name: "invalid name", Annotations: map[string]string{"headers": `{"X Mode": "full"}`}, WantErr: true},
+		{Name: "invalid value", Annotations: map[string]string{"headers": `{"X-Mode": "full\r\nX-Other: 1"}`}, WantErr: true},
+		{Name: "reserved header", Annotations: map[string]string{"headers": `{"x-connector": "other"}`}, WantErr: true},
+		{Name: "reserved secret header", Annotations: map[string]string{"secret_headers": `{"X-Topic": "topic"}`}, WantErr: true},
+		{Name: "secret outside of mount path", Annotations: map[string]string{"secret_headers": `{"Authorization": "../token"}`}, WantErr: true},
+		{Name: "connector's password", Annotations: map[string]string{"secret_headers": `{"X-A": "basic-auth-password"}`}, WantErr: true},
+		{Name: "connector's user", Annotations: map[string]string{"secret_headers": `{"X-A": "basic-auth-user"}`}, WantErr: true},
+		{Name: "header set twice", Annotations: map[string]string{"headers": `{"Authorization": "a"}`, "secret_headers": `{"authorization": "token"}`}, WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			testCronFunctions(t, tc.WantErr, Defaults{HeaderSecretsPath: "/var/openfaas/header-secrets"}, tc.Annotations)
+		})
+	}
+}
+
+func TestToCronFunctions_SecretHeadersPath(t *testing.T) {
+	annotations := map[string]string{"secret_headers": `{"Authorization": "cleanup-token"}`}
+
+	testCronFunctions(t, true, Defaults{}, annotations)
+
+	cfs := testCronFunctions(t, false, Defaults{HeaderSecretsPath: "/var/openfaas/header-secrets"}, annotations)
+	if want, got := "/var/openfaas/header-secrets/openfaas-fn/cleanup-token", cfs[0].SecretHeaders["Authorization"]; got != want {
+		t.Errorf("expected the secret to be read from the namespace's directory: %s, got: %s", want, got)
+	}
+}
+
+func TestInvokeFunction_Headers(t *testing.T) {
+	secrets := t.TempDir()
+	if err := os.Mkdir(filepath.Join(secrets, "openfaas-fn"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(secrets, "openfaas-fn", "cleanup-token"), []byte("Bearer s3cr3t\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got http.Header
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}, 1)
+	invoker.ContentType = "text/plain"
+
+	annotations := map[string]string{
+		"topic":          "cron-function",
+		"schedule":       "* * * * *",
+		"headers":        `{"x-mode": "full"}`,
+		"secret_headers": `{"Authorization": "cleanup-token"}`,
+	}
+	f := ptypes.FunctionStatus{Name: "cleanup", Annotations: &annotations}
+
+	cfs, err := ToCronFunctions(f, "openfaas-fn", "cron-function", Defaults{HeaderSecretsPath: secrets})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := cfs[0].InvokeFunction(context.Background(), invoker, NewRun(time.Now())); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := map[string]string{
+		"X-Mode":        "full",
+		"Authorization": "Bearer s3cr3t",
+		"X-Topic":       "cron-function",
+		"X-Connector":   "cron-connector",
+	}
+	for name, value := range want {
+		if got.Get(name) != value {
+			t.Errorf("%s expected: %q, got: %q", name, value, got.Get(name))
+		}
+	}
+}
+
+func TestInvokeFunction_MissingSecret(t *testing.T) {
+	invoker := &types.Invoker{
+		Client:     http.DefaultClient,
+		GatewayURL: "http://127.0.0.1:0/function",
+		Responses:  make(chan types.InvokerResponse, 1),
+	}
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:      ptypes.FunctionStatus{Annotations: &annotations},
+		Name:          "cleanup",
+		SecretHeaders: map[string]string{"Authorization": filepath.Join(t.TempDir(), "missing")},
+	}
+
+	if _, err := c.InvokeFunction(context.Background(), invoker, NewRun(time.Now())); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if res := <-invoker.Responses; res.Error == nil {
+		t.Error("expected the error to be reported as a response")
+	}
+}
+
+func TestInvokeFunction_RunHeaders(t *testing.T) {
+	var got http.Header
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}, 1)
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+		Name:      "nightly",
+		Namespace: "openfaas-fn",
+		Schedule:  "0 2 * * *",
+	}
+
+	scheduled := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+	run := NewRun(scheduled)
+
+	if _, err := c.InvokeFunction(context.Background(), invoker, run); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if v := got.Get(ScheduledTimeHeader); v != "2024-06-01T02:00:00Z" {
+		t.Errorf("%s expected: %s, got: %s", ScheduledTimeHeader, "2024-06-01T02:00:00Z", v)
+	}
+	if v := got.Get(ScheduleHeader); v != "0 2 * * *" {
+		t.Errorf("%s expected: %s, got: %s", ScheduleHeader, "0 2 * * *", v)
+	}
+	if v := got.Get(RunIDHeader); v != run.ID {
+		t.Errorf("%s expected: %s, got: %s", RunIDHeader, run.ID, v)
+	}
+	if v := got.Get(TriggerHeader); v != "schedule" {
+		t.Errorf("%s expected: %s, got: %s", TriggerHeader, "schedule", v)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, got.Get(DispatchTimeHeader)); err != nil {
+		t.Errorf("%s is not a valid time: %s", DispatchTimeHeader, err)
+	}
+
+	key := got.Get(IdempotencyKeyHeader)
+	if key != c.IdempotencyKey(scheduled) {
+		t.Errorf("%s expected: %s, got: %s", IdempotencyKeyHeader, c.IdempotencyKey(scheduled), key)
+	}
+
+	if c.IdempotencyKey(scheduled) != c.IdempotencyKey(scheduled.In(time.FixedZone("UTC+2", 7200))) {
+		t.Error("idempotency key should not depend on the time zone of the scheduled time")
+	}
+	if c.IdempotencyKey(scheduled) == c.IdempotencyKey(scheduled.Add(time.Minute)) {
+		t.Error("idempotency key should differ for each scheduled time")
+	}
+
+	other := c
+	other.Namespace = "staging"
+	if c.IdempotencyKey(scheduled) == other.IdempotencyKey(scheduled) {
+		t.Error("idempotency key should differ for each namespace")
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/openfaas/connector-sdk/types"
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+// testCronFunctions converts a function named "job", scheduled every minute
+// on the cron-function topic, with each of annotations applied in turn. It
+// fails unless an error is returned exactly when wantErr is set.
+func testCronFunctions(t *testing.T, wantErr bool, defaults Defaults, annotations ...map[string]string) CronFunctions {
+	t.Helper()
+
+	merged := map[string]string{
+		"topic":    "cron-function",
+		"schedule": "* * * * *",
+	}
+	for _, a := range annotations {
+		for k, v := range a {
+			merged[k] = v
+		}
+	}
+	f := ptypes.FunctionStatus{Name: "job", Annotations: &merged}
+
+	cfs, err := ToCronFunctions(f, "openfaas-fn", "cron-function", defaults)
+	if wantErr && err == nil {
+		t.Fatal("expected an error")
+	}
+	if !wantErr && err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return cfs
+}
+
+// testInvoker returns an Invoker for a gateway served by handler, with
+// room for responses before they must be read. The gateway is closed when
+// the test finishes.
+func testInvoker(t *testing.T, handler http.HandlerFunc, responses int) *types.Invoker {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return &types.Invoker{
+		Client:     srv.Client(),
+		GatewayURL: srv.URL + "/function",
+		Responses:  make(chan types.InvokerResponse, responses),
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openfaas/connector-sdk/types"
+)
+
+// RunRecord is the outcome of one attempt to invoke a function
+type RunRecord struct {
+	RunID     string `json:"run_id,omitempty"`
+	Function  string `json:"function"`
+	Namespace string `json:"namespace,omitempty"`
+	Attempt   int    `json:"attempt,omitempty"`
+	Manual    bool   `json:"manual,omitempty"`
+
+	// Scheduled is the time the run was planned for
+	Scheduled time.Time `json:"scheduled"`
+
+	// Started is the time the attempt was made
+	Started time.Time `json:"started"`
+
+	Duration time.Duration `json:"duration_ns"`
+	Status   int           `json:"status"`
+	Error    string        `json:"error,omitempty"`
+
+	// Body is the start of the response body, Truncated is set when
+	// the rest of it was dropped
+	Body      string `json:"body,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// NewRunRecord returns the record for a response from the invoker, keeping
+// at most bodyLimit bytes of the response body
+func NewRunRecord(r types.InvokerResponse, bodyLimit int) RunRecord {
+	record := RunRecord{
+		Function: r.Function,
+		Started:  time.Now().Add(-r.Duration),
+		Duration: r.Duration,
+		Status:   r.Status,
+	}
+
+	if run, ok := RunFromContext(r.Context); ok {
+		record.RunID = run.ID
+		record.Namespace = run.Namespace
+		record.Attempt = run.Attempt
+		record.Manual = run.Manual
+		record.Scheduled = run.Scheduled
+	}
+	if record.Scheduled.IsZero() {
+		record.Scheduled = record.Started
+	}
+
+	if r.Error != nil {
+		record.Error = r.Error.Error()
+	}
+
+	if r.Body != nil {
+		body := *r.Body
+		if len(body) > bodyLimit {
+			body = body[:bodyLimit]
+			record.Truncated = true
+		}
+		record.Body = string(body)
+	}
+
+	return record
+}
+
+// HistoryLimits bound how many records a RunHistory keeps
+type HistoryLimits struct {
+	// MaxRuns is the number of records kept, the oldest are
+	// dropped first
+	MaxRuns int
+
+	// Retention is how long records are kept for, when zero
+	// records are kept until MaxRuns is reached
+	Retention time.Duration
+}
+
+// RunHistory keeps a record of each run in a file on local disk, with one
+// JSON record per line. Records are appended as runs complete, and the file
+// is rewritten once enough records have expired.
+type RunHistory struct {
+	path   string
+	limits HistoryLimits
+
+	mu      sync.Mutex
+	records []RunRecord
+	file    *os.File
+
+	// lines counts the records in the file, including expired ones
+	lines int
+}
+
+// NewRunHistory loads the run history from path, a missing file gives
+// an empty history
+func NewRunHistory(path string, limits HistoryLimits) (*RunHistory, error) {
+	if limits.MaxRuns < 1 {
+		return nil, fmt.Errorf("max runs must be at least 1, got: %d", limits.MaxRuns)
+	}
+
+	h := &RunHistory{
+		path:   path,
+		limits: limits,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record RunRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// A crash part-way through a write leaves a partial line behind
+			log.Printf("Skipping unreadable record in %s: %s", path, err)
+			continue
+		}
+		h.records = append(h.records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read run history %s: %w", path, err)
+	}
+
+	h.prune(time.Now())
+	if err := h.compact(); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// Add appends record to the history
+func (h *RunHistory) Add(record RunRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.file == nil {
+		return fmt.Errorf("run history %s is closed", h.path)
+	}
+
+	if _, err := h.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	h.lines++
+
+	h.records = append(h.records, record)
+	h.prune(time.Now())
+
+	// Rewrite the file once it holds as many expired records as live ones
+	if h.lines > 2*len(h.records) && h.lines > h.limits.MaxRuns {
+		return h.compact()
+	}
+
+	return nil
+}
+
+// Close closes the history's file, records can no longer be added
+func (h *RunHistory) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.file == nil {
+		return nil
+	}
+
+	err := h.file.Close()
+	h.file = nil
+	return err
+}
+
+// Query returns the records which match q, the most recent first
+func (h *RunHistory) Query(q RunQuery) []RunRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	records := []RunRecord{}
+	for i := len(h.records) - 1; i >= 0; i-- {
+		if q.Limit > 0 && len(records) >= q.Limit {
+			break
+		}
+		if q.matches(h.records[i]) {
+			records = append(records, h.records[i])
+		}
+	}
+
+	return records
+}
+
+// prune drops records which are older than the retention period, then
+// the oldest records over MaxRuns
+func (h *RunHistory) prune(now time.Time) {
+	if h.limits.Retention > 0 {
+		cutoff := now.Add(-h.limits.Retention)
+		h.records = slices.DeleteFunc(h.records, func(r RunRecord) bool {
+			return r.Started.Before(cutoff)
+		})
+	}
+
+	if over := len(h.records) - h.limits.MaxRuns; over > 0 {
+		h.records = slices.Delete(h.records, 0, over)
+	}
+}
+
+// compact rewrites the file with only the records which are kept, and
+// opens it to append new records
+func (h *RunHistory) compact() error {
+	var data bytes.Buffer
+	for _, record := range h.records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		data.Write(append(line, '\n'))
+	}
+
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+
+	if err := writeFileAtomic(h.path, data.Bytes()); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	h.file = file
+	h.lines = len(h.records)
+	return nil
+}
+
+// RunQuery selects records from a RunHistory, empty fields match
+// all records
+type RunQuery struct {
+	Function  string
+	Namespace string
+
+	// Status is a status code such as "500", or a class of status
+	// codes such as "5xx"
+	Status string
+
+	// Since and Until bound the time the runs were scheduled for
+	Since time.Time
+	Until time.Time
+
+	// Limit is the most records returned, when zero all matching
+	// records are returned
+	Limit int
+}
+
+// ParseRunQuery reads a RunQuery from the query string of a request, with
+// the parameters function, namespace, status, since, until and limit.
+// Times are given in RFC3339 format.
+func ParseRunQuery(values url.Values) (RunQuery, error) {
+	q := RunQuery{
+		Function:  values.Get("function"),
+		Namespace: values.Get("namespace"),
+		Status:    strings.ToLower(values.Get("status")),
+	}
+
+	if len(q.Status) > 0 && !validStatusFilter(q.Status) {
+		return RunQuery{}, fmt.Errorf("invalid status: %q, use a status code such as 500 or a class such as 5xx", q.Status)
+	}
+
+	for name, t := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if v := values.Get(name); len(v) > 0 {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return RunQuery{}, fmt.Errorf("invalid %s: %q, use RFC3339 format", name, v)
+			}
+			*t = parsed
+		}
+	}
+
+	if v := values.Get("limit"); len(v) > 0 {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return RunQuery{}, fmt.Errorf("invalid limit: %q", v)
+		}
+		q.Limit = limit
+	}
+
+	return q, nil
+}
+
+func validStatusFilter(status string) bool {
+	if len(status) != 3 {
+		return false
+	}
+
+	if strings.HasSuffix(status, "xx") {
+		return status[0] >= '1' && status[0] <= '5'
+	}
+
+	_, err := strconv.Atoi(status)
+	return err == nil
+}
+
+func (q *RunQuery) matches(r RunRecord) bool {
+	if len(q.Function) > 0 && r.Function != q.Function {
+		return false
+	}
+	if len(q.Namespace) > 0 && r.Namespace != q.Namespace {
+		return false
+	}
+	if !q.Since.IsZero() && r.Scheduled.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && r.Scheduled.After(q.Until) {
+		return false
+	}
+
+	if len(q.Status) > 0 {
+		status := strconv.Itoa(r.Status)
+		if strings.HasSuffix(q.Status, "xx") {
+			return status[0] == q.Status[0]
+		}
+		return status == q.Status
+	}
+
+	return true
+}
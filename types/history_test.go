@@ -0,0 +1,170 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openfaas/connector-sdk/types"
+)
+
+func TestNewRunRecord(t *testing.T) {
+	scheduled := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+	run := NewRun(scheduled)
+	run.Function, run.Namespace, run.Attempt = "nightly", "openfaas-fn", 2
+
+	body := []byte("0123456789")
+	record := NewRunRecord(types.InvokerResponse{
+		Context:  WithRun(context.Background(), run),
+		Body:     &body,
+		Status:   500,
+		Error:    errors.New("boom"),
+		Function: "nightly",
+		Duration: time.Second,
+	}, 4)
+
+	if record.RunID != run.ID || record.Namespace != "openfaas-fn" || record.Attempt != 2 {
+		t.Errorf("expected the run to be recorded, got: %+v", record)
+	}
+	if !record.Scheduled.Equal(scheduled) {
+		t.Errorf("expected: %s, got: %s", scheduled, record.Scheduled)
+	}
+	if record.Body != "0123" || !record.Truncated {
+		t.Errorf("expected a truncated body, got: %q truncated: %v", record.Body, record.Truncated)
+	}
+	if record.Error != "boom" {
+		t.Errorf("expected: %s, got: %s", "boom", record.Error)
+	}
+}
+
+func TestRunHistory_Query(t *testing.T) {
+	h, err := NewRunHistory(filepath.Join(t.TempDir(), "history.jsonl"), HistoryLimits{MaxRuns: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer h.Close()
+
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	records := []RunRecord{
+		{RunID: "1", Function: "nightly", Namespace: "openfaas-fn", Status: 200, Scheduled: base},
+		{RunID: "2", Function: "hourly", Namespace: "openfaas-fn", Status: 502, Scheduled: base.Add(time.Hour)},
+		{RunID: "3", Function: "nightly", Namespace: "staging", Status: 500, Scheduled: base.Add(2 * time.Hour)},
+		{RunID: "4", Function: "nightly", Namespace: "openfaas-fn", Status: 200, Scheduled: base.Add(3 * time.Hour)},
+	}
+	for _, record := range records {
+		record.Started = time.Now()
+		if err := h.Add(record); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	testcases := []struct {
+		Name  string
+		Query string
+		Want  string
+	}{
+		{Name: "all runs, most recent first", Query: "", Want: "4,3,2,1"},
+		{Name: "by function", Query: "function=nightly", Want: "4,3,1"},
+		{Name: "by function and namespace", Query: "function=nightly&namespace=openfaas-fn", Want: "4,1"},
+		{Name: "by status code", Query: "status=502", Want: "2"},
+		{Name: "by status class", Query: "status=5xx", Want: "3,2"},
+		{Name: "by time range", Query: "since=2024-06-01T01:00:00Z&until=2024-06-01T02:00:00Z", Want: "3,2"},
+		{Name: "with limit", Query: "limit=2", Want: "4,3"},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tc.Query)
+			q, err := ParseRunQuery(values)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			ids := []string{}
+			for _, record := range h.Query(q) {
+				ids = append(ids, record.RunID)
+			}
+
+			if got := strings.Join(ids, ","); got != tc.Want {
+				t.Errorf("expected: %s, got: %s", tc.Want, got)
+			}
+		})
+	}
+}
+
+func TestParseRunQuery_Invalid(t *testing.T) {
+	for _, query := range []string{"status=abc", "status=6xx", "status=20", "since=yesterday", "limit=-1"} {
+		values, _ := url.ParseQuery(query)
+		if _, err := ParseRunQuery(values); err == nil {
+			t.Errorf("expected an error for %q", query)
+		}
+	}
+}
+
+func TestRunHistory_Retention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	h, err := NewRunHistory(path, HistoryLimits{MaxRuns: 3, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := h.Add(RunRecord{RunID: "expired", Function: "nightly", Started: time.Now().Add(-2 * time.Hour)}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, id := range []string{"1", "2", "3", "4", "5", "6", "7"} {
+		if err := h.Add(RunRecord{RunID: id, Function: "nightly", Started: time.Now()}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	h.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines > 6 {
+		t.Errorf("expected the file to be compacted, got %d lines", lines)
+	}
+
+	reloaded, err := NewRunHistory(path, HistoryLimits{MaxRuns: 3, Retention: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer reloaded.Close()
+
+	ids := []string{}
+	for _, record := range reloaded.Query(RunQuery{}) {
+		ids = append(ids, record.RunID)
+	}
+
+	if got := strings.Join(ids, ","); got != "7,6,5" {
+		t.Errorf("expected: %s, got: %s", "7,6,5", got)
+	}
+}
+
+func TestRunHistory_SkipsPartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	data := `{"run_id":"1","function":"nightly","started":"` + time.Now().Format(time.RFC3339) + `"}` + "\n" + `{"run_id":"2","func`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewRunHistory(path, HistoryLimits{MaxRuns: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer h.Close()
+
+	if records := h.Query(RunQuery{}); len(records) != 1 || records[0].RunID != "1" {
+		t.Errorf("expected the complete record only, got: %+v", records)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+)
+
+// JitterMode decides how the delay within the jitter window is chosen
+type JitterMode string
+
+const (
+	// RandomJitter picks a new delay for each run
+	RandomJitter JitterMode = "random"
+
+	// HashJitter derives the delay from the function's name and
+	// namespace, so that it is the same for every run and replica
+	HashJitter JitterMode = "hash"
+)
+
+// ParseJitterMode returns the JitterMode named by value, an empty value
+// selects RandomJitter
+func ParseJitterMode(value string) (JitterMode, error) {
+	switch JitterMode(value) {
+	case "", RandomJitter:
+		return RandomJitter, nil
+	case HashJitter:
+		return HashJitter, nil
+	}
+
+	return "", fmt.Errorf("unknown jitter mode: %q, use %q or %q", value, RandomJitter, HashJitter)
+}
+
+// readJitter returns the jitter window and mode from the jitter and
+// jitter_mode annotations, falling back to defaults
+func readJitter(annotations map[string]string, defaults Defaults) (time.Duration, JitterMode, error) {
+	window := defaults.Jitter
+	if v, ok := annotations["jitter"]; ok {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return 0, "", fmt.Errorf("jitter must be a positive duration: %q", v)
+		}
+		window = d
+	}
+
+	mode := defaults.JitterMode
+	if v, ok := annotations["jitter_mode"]; ok {
+		m, err := ParseJitterMode(v)
+		if err != nil {
+			return 0, "", err
+		}
+		mode = m
+	}
+
+	if len(mode) == 0 {
+		mode = RandomJitter
+	}
+
+	return window, mode, nil
+}
+
+// jitterDelay returns how long to wait before invoking the function,
+// within its jitter window
+func (c *CronFunction) jitterDelay() time.Duration {
+	if c.Jitter <= 0 {
+		return 0
+	}
+
+	if c.JitterMode == HashJitter {
+		h := fnv.New64a()
+		h.Write([]byte(c.Namespace + "/" + c.Name))
+		return time.Duration(h.Sum64() % uint64(c.Jitter))
+	}
+
+	return rand.N(c.Jitter)
+}
@@ -0,0 +1,132 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestReadJitter(t *testing.T) {
+	testcases := []struct {
+		Name        string
+		Annotations map[string]string
+		Defaults    Defaults
+		WantWindow  time.Duration
+		WantMode    JitterMode
+		WantErr     bool
+	}{
+		{Name: "no jitter", Annotations: map[string]string{}, WantMode: RandomJitter},
+		{Name: "connector default", Annotations: map[string]string{}, Defaults: Defaults{Jitter: time.Minute, JitterMode: HashJitter}, WantWindow: time.Minute, WantMode: HashJitter},
+		{Name: "annotations override defaults", Annotations: map[string]string{"jitter": "30s", "jitter_mode": "random"}, Defaults: Defaults{Jitter: time.Minute, JitterMode: HashJitter}, WantWindow: 30 * time.Second, WantMode: RandomJitter},
+		{Name: "invalid window", Annotations: map[string]string{"jitter": "a bit"}, WantErr: true},
+		{Name: "negative window", Annotations: map[string]string{"jitter": "-5s"}, WantErr: true},
+		{Name: "invalid mode", Annotations: map[string]string{"jitter": "5s", "jitter_mode": "sticky"}, WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			window, mode, err := readJitter(tc.Annotations, tc.Defaults)
+			if tc.WantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if window != tc.WantWindow || mode != tc.WantMode {
+				t.Errorf("expected: %s %s, got: %s %s", tc.WantWindow, tc.WantMode, window, mode)
+			}
+		})
+	}
+}
+
+func TestJitterDelay_Hash(t *testing.T) {
+	c := CronFunction{Name: "report", Namespace: "openfaas-fn", Jitter: 5 * time.Minute, JitterMode: HashJitter}
+
+	delay := c.jitterDelay()
+	if delay < 0 || delay >= c.Jitter {
+		t.Fatalf("expected a delay within %s, got: %s", c.Jitter, delay)
+	}
+
+	for i := 0; i < 10; i++ {
+		if got := c.jitterDelay(); got != delay {
+			t.Fatalf("expected the same delay for each run, got: %s and %s", delay, got)
+		}
+	}
+
+	other := c
+	other.Namespace = "staging"
+	if other.jitterDelay() == delay {
+		t.Error("expected a different delay for a function in another namespace")
+	}
+}
+
+func TestJitterDelay_Random(t *testing.T) {
+	c := CronFunction{Name: "report", Namespace: "openfaas-fn", Jitter: time.Second, JitterMode: RandomJitter}
+
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 20; i++ {
+		delay := c.jitterDelay()
+		if delay < 0 || delay >= c.Jitter {
+			t.Fatalf("expected a delay within %s, got: %s", c.Jitter, delay)
+		}
+		seen[delay] = true
+	}
+
+	if len(seen) < 2 {
+		t.Error("expected the delay to vary between runs")
+	}
+
+	c.Jitter = 0
+	if c.jitterDelay() != 0 {
+		t.Error("expected no delay without a jitter window")
+	}
+}
+
+func TestJob_CancelledDuringJitter(t *testing.T) {
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("expected the function not to be invoked")
+	}, 1)
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:          ptypes.FunctionStatus{Annotations: &annotations},
+		Name:              "report",
+		Namespace:         "openfaas-fn",
+		Schedule:          "* * * * *",
+		ConcurrencyPolicy: ReplaceConcurrent,
+		Jitter:            time.Hour,
+		JitterMode:        HashJitter,
+	}
+	if delay := c.jitterDelay(); delay < time.Second {
+		t.Fatalf("expected a delay of at least a second, got: %s", delay)
+	}
+
+	s := NewScheduler()
+	go (&cronJob{function: c, invoker: invoker, scheduler: s}).run(time.Now())
+
+	time.Sleep(50 * time.Millisecond)
+	_, done, _ := s.startRun(c, NewRun(time.Now()))
+	defer done()
+
+	select {
+	case res := <-invoker.Responses:
+		if !errors.Is(res.Error, ErrRunReplaced) {
+			t.Errorf("expected the error to be %s, got: %v", ErrRunReplaced, res.Error)
+		}
+		if _, ok := RunFromContext(res.Context); !ok {
+			t.Error("expected the response to carry the run")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the cancelled run to be reported")
+	}
+}
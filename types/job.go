@@ -0,0 +1,200 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openfaas/connector-sdk/types"
+	cron "github.com/robfig/cron/v3"
+)
+
+// cronJob invokes a CronFunction each time its cron entry fires
+type cronJob struct {
+	invoker   *types.Invoker
+	scheduler *Scheduler
+
+	// function is replaced when the function's spec changes
+	mu       sync.Mutex
+	function CronFunction
+
+	// schedule is replaced when the job is rescheduled
+	schedule atomic.Pointer[plannedSchedule]
+}
+
+// Run is called by cron in a new goroutine
+func (j *cronJob) Run() {
+	j.run(j.scheduled())
+}
+
+// run invokes the function for the run which was planned for scheduled
+func (j *cronJob) run(scheduled time.Time) {
+	run := NewRun(scheduled)
+	c := j.spec()
+
+	// The state is only read to catch up on missed runs
+	if state := j.scheduler.state; state != nil && c.StartingDeadline > 0 {
+		state.Record(c.stateKey(), scheduled)
+	}
+
+	// Runs of suspended functions are still recorded, so that they
+	// are not caught up on when the connector restarts
+	if c.Suspended || j.scheduler.Paused(c) {
+		log.Printf("Suspended: %s [%s], skipping run", c.String(), c.ScheduleString())
+		return
+	}
+
+	// Standbys record runs too, in case they take over later
+	if !j.scheduler.leading() {
+		log.Printf("Standby: %s [%s], the leader runs this function", c.String(), c.ScheduleString())
+		return
+	}
+
+	claimed, err := j.scheduler.claim(c, run)
+	if err != nil {
+		log.Printf("Error claiming run of %s [%s], not starting it: %s", c.String(), c.ScheduleString(), err)
+		c.reportNotStarted(j.invoker, run, fmt.Errorf("%w: %w", ErrRunLockFailed, err), http.StatusServiceUnavailable)
+		return
+	}
+	if !claimed {
+		log.Printf("Claimed: %s [%s], another replica is running it", c.String(), c.ScheduleString())
+		return
+	}
+
+	ctx, done, ok := j.scheduler.startRun(c, run)
+	if !ok {
+		if j.scheduler.Stopping() {
+			log.Printf("Dropped: %s [%s], the connector is shutting down", c.String(), c.ScheduleString())
+			return
+		}
+
+		log.Printf("Skipped: %s [%s], the previous run is still in flight", c.String(), c.ScheduleString())
+		c.reportSkipped(j.invoker, run)
+		return
+	}
+	defer done()
+
+	if delay := c.jitterDelay(); delay > 0 {
+		log.Printf("Delaying: %s [%s] by %s", c.String(), c.ScheduleString(), delay.Round(time.Millisecond))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			log.Printf("Cancelled: %s [%s] %s", c.String(), c.ScheduleString(), context.Cause(ctx))
+			c.reportNotStarted(j.invoker, run, context.Cause(ctx), errorStatus(context.Cause(ctx)))
+			return
+		}
+		run.Started = time.Now()
+	}
+
+	log.Printf("Invoking: %s [%s]", c.String(), c.ScheduleString())
+	if _, err := c.InvokeFunction(ctx, j.invoker, run); err != nil {
+		log.Printf("Error: %s", err)
+	}
+}
+
+// spec returns the function as it is now
+func (j *cronJob) spec() CronFunction {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.function
+}
+
+func (j *cronJob) setSpec(c CronFunction) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.function = c
+}
+
+// scheduled returns the time the job was planned to run at
+func (j *cronJob) scheduled() time.Time {
+	if schedule := j.schedule.Load(); schedule != nil {
+		if planned := schedule.planned(time.Now()); !planned.IsZero() {
+			return planned
+		}
+	}
+
+	return time.Now()
+}
+
+// plannedSchedule remembers the times cron was given by the schedule it
+// wraps, so that a job can tell which of them it was started for
+type plannedSchedule struct {
+	cron.Schedule
+
+	mu   sync.Mutex
+	prev time.Time
+	next time.Time
+}
+
+func newPlannedSchedule(schedule cron.Schedule) *plannedSchedule {
+	return &plannedSchedule{Schedule: schedule}
+}
+
+// Next returns the next time the schedule is activated, after t
+func (p *plannedSchedule) Next(t time.Time) time.Time {
+	next := p.Schedule.Next(t)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !next.Equal(p.next) {
+		p.prev, p.next = p.next, next
+	}
+
+	return next
+}
+
+// planned returns the latest time given to cron which is not after now.
+// cron asks for the following time as it starts the job, so the time the
+// job was started for may already be the previous one.
+func (p *plannedSchedule) planned(now time.Time) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.next.IsZero() && !p.next.After(now) {
+		return p.next
+	}
+
+	return p.prev
+}
+
+// Trigger runs c now, outside of its schedule. The run is marked as manual
+// and is subject to the function's concurrency policy, but not to its
+// jitter. ErrRunSkipped is returned if the run cannot start.
+func (s *Scheduler) Trigger(c CronFunction, invoker *types.Invoker) (Run, error) {
+	run := NewRun(time.Now())
+	run.Function, run.Namespace = c.Name, c.Namespace
+	run.Manual = true
+
+	ctx, done, ok := s.startRun(c, run)
+	if !ok {
+		if s.Stopping() {
+			return run, ErrShuttingDown
+		}
+
+		log.Printf("Skipped: %s [%s] manual run, the previous run is still in flight", c.String(), c.ScheduleString())
+		c.reportSkipped(invoker, run)
+		return run, fmt.Errorf("%w: %s is still running", ErrRunSkipped, c.String())
+	}
+
+	go func() {
+		defer done()
+
+		log.Printf("Invoking: %s [%s] manual run %s", c.String(), c.ScheduleString(), run.ID)
+		if _, err := c.InvokeFunction(ctx, invoker, run); err != nil {
+			log.Printf("Error: %s", err)
+		}
+	}()
+
+	return run, nil
+}
@@ -0,0 +1,153 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Lease is held by at most one replica of the connector at a time, the
+// replica which holds it is the leader
+type Lease interface {
+	// Acquire takes the lease for identity if it is free or has
+	// expired, or renews it if identity already holds it. It returns
+	// true if identity holds the lease for the next duration.
+	Acquire(ctx context.Context, identity string, duration time.Duration) (bool, error)
+
+	// Release frees the lease if identity holds it, so that another
+	// replica can take over straight away
+	Release(ctx context.Context, identity string) error
+}
+
+// LeaderElection decides whether this replica of the connector is the
+// leader, by acquiring and renewing a Lease
+type LeaderElection struct {
+	lease    Lease
+	identity string
+
+	// duration is how long the lease lasts without being renewed
+	duration time.Duration
+
+	// retryPeriod is how often the lease is acquired or renewed
+	retryPeriod time.Duration
+
+	// renewDeadline is how long the leader keeps leading without
+	// renewing the lease, it is shorter than duration so that the
+	// leader steps down before another replica can take over
+	renewDeadline time.Duration
+
+	leading atomic.Bool
+
+	// renewed is when the lease was last acquired or renewed, in
+	// nanoseconds since the Unix epoch
+	renewed atomic.Int64
+}
+
+// NewLeaderElection returns a LeaderElection for identity, which must be
+// unique to each replica, using a lease which lasts for duration
+func NewLeaderElection(lease Lease, identity string, duration time.Duration) *LeaderElection {
+	return &LeaderElection{
+		lease:         lease,
+		identity:      identity,
+		duration:      duration,
+		retryPeriod:   duration / 3,
+		renewDeadline: duration * 2 / 3,
+	}
+}
+
+// IsLeader returns true if this replica holds the lease. A leader which
+// has not renewed the lease within renewDeadline is no longer the leader,
+// even while a renewal is still in progress.
+func (l *LeaderElection) IsLeader() bool {
+	return l.leading.Load() && l.sinceRenewed() <= l.renewDeadline
+}
+
+func (l *LeaderElection) sinceRenewed() time.Duration {
+	return time.Since(time.Unix(0, l.renewed.Load()))
+}
+
+// Run acquires and renews the lease until ctx is done, then releases it
+func (l *LeaderElection) Run(ctx context.Context) {
+	ticker := time.NewTicker(l.retryPeriod)
+	defer ticker.Stop()
+
+	for {
+		acquired, err := l.acquire(ctx)
+		if err != nil {
+			log.Printf("Error acquiring leader lease: %s", err)
+		}
+
+		switch {
+		case acquired:
+			l.setLeading(true)
+		case err == nil:
+			l.setLeading(false)
+		case l.sinceRenewed() > l.renewDeadline:
+			// The lease could not be renewed in time, another
+			// replica may take it over
+			l.setLeading(false)
+		}
+
+		select {
+		case <-ctx.Done():
+			l.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// acquire makes one attempt to acquire or renew the lease. The leader gives
+// up once renewDeadline has passed since the last renewal, so that an
+// attempt which hangs cannot outlast the lease. The lease is held from
+// the start of the attempt, as it may have been written straight away.
+func (l *LeaderElection) acquire(ctx context.Context) (bool, error) {
+	timeout := l.renewDeadline
+	if l.leading.Load() {
+		timeout = l.renewDeadline - l.sinceRenewed()
+	}
+	if timeout <= 0 {
+		return false, context.DeadlineExceeded
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	acquired, err := l.lease.Acquire(ctx, l.identity, l.duration)
+	if acquired && err == nil {
+		l.renewed.Store(start.UnixNano())
+	}
+
+	return acquired, err
+}
+
+func (l *LeaderElection) setLeading(leading bool) {
+	if l.leading.Swap(leading) == leading {
+		return
+	}
+
+	if leading {
+		log.Printf("Leader election: %s is the leader", l.identity)
+	} else {
+		log.Printf("Leader election: %s is on standby", l.identity)
+	}
+}
+
+func (l *LeaderElection) release() {
+	if !l.leading.Load() {
+		return
+	}
+	l.setLeading(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.retryPeriod)
+	defer cancel()
+
+	if err := l.lease.Release(ctx, l.identity); err != nil {
+		log.Printf("Error releasing leader lease: %s", err)
+	}
+}
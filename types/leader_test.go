@@ -0,0 +1,321 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+// testLease checks the behaviour shared by each Lease backend
+func testLease(t *testing.T, lease Lease) {
+	t.Helper()
+	ctx := context.Background()
+
+	steps := []struct {
+		Name     string
+		Identity string
+		Release  bool
+		Duration time.Duration
+		Want     bool
+	}{
+		{Name: "free lease is acquired", Identity: "a", Duration: time.Minute, Want: true},
+		{Name: "held lease is not acquired", Identity: "b", Duration: time.Minute, Want: false},
+		{Name: "holder renews", Identity: "a", Duration: time.Minute, Want: true},
+		{Name: "non-holder cannot release", Identity: "b", Release: true},
+		{Name: "still held after release by non-holder", Identity: "b", Duration: time.Minute, Want: false},
+		{Name: "holder releases", Identity: "a", Release: true},
+		{Name: "released lease is acquired", Identity: "b", Duration: time.Second, Want: true},
+		{Name: "held until it expires", Identity: "a", Duration: time.Minute, Want: false},
+	}
+
+	for _, step := range steps {
+		if step.Release {
+			if err := lease.Release(ctx, step.Identity); err != nil {
+				t.Fatalf("%s: unexpected error: %s", step.Name, err)
+			}
+			continue
+		}
+
+		got, err := lease.Acquire(ctx, step.Identity, step.Duration)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", step.Name, err)
+		}
+		if got != step.Want {
+			t.Fatalf("%s: expected: %v, got: %v", step.Name, step.Want, got)
+		}
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	if got, err := lease.Acquire(ctx, "a", time.Minute); err != nil || !got {
+		t.Fatalf("expected an expired lease to be acquired, got: %v %v", got, err)
+	}
+}
+
+func TestFileLease(t *testing.T) {
+	testLease(t, NewFileLease(filepath.Join(t.TempDir(), "state", "leader.lease")))
+}
+
+func TestKubernetesLease(t *testing.T) {
+	api := newFakeLeaseAPI()
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("sa-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	testLease(t, newKubernetesLease(srv.Client(), srv.URL, tokenPath, "openfaas", "cron-connector"))
+
+	if api.token != "Bearer sa-token" {
+		t.Errorf("expected the service account token to be sent, got: %q", api.token)
+	}
+}
+
+func TestKubernetesLease_Conflict(t *testing.T) {
+	api := newFakeLeaseAPI()
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	os.WriteFile(tokenPath, []byte("sa-token"), 0600)
+
+	lease := newKubernetesLease(srv.Client(), srv.URL, tokenPath, "openfaas", "cron-connector")
+	if ok, err := lease.Acquire(context.Background(), "a", time.Second); err != nil || !ok {
+		t.Fatalf("expected the lease to be acquired, got: %v %v", ok, err)
+	}
+
+	// Another replica updates the lease between the read and the write
+	api.beforeUpdate = func() { api.resourceVersion++ }
+	time.Sleep(1100 * time.Millisecond)
+
+	if ok, err := lease.Acquire(context.Background(), "b", time.Second); err != nil || ok {
+		t.Fatalf("expected the lease not to be acquired on a conflict, got: %v %v", ok, err)
+	}
+}
+
+// fakeLeaseAPI serves a single Lease object, as the Kubernetes API would
+type fakeLeaseAPI struct {
+	mu              sync.Mutex
+	lease           *kubernetesLease
+	resourceVersion int
+	token           string
+	beforeUpdate    func()
+}
+
+func newFakeLeaseAPI() *fakeLeaseAPI {
+	return &fakeLeaseAPI{}
+}
+
+func (f *fakeLeaseAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.token = r.Header.Get("Authorization")
+
+	const collection = "/apis/coordination.k8s.io/v1/namespaces/openfaas/leases"
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == collection+"/cron-connector":
+		if f.lease == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(f.lease)
+
+	case r.Method == http.MethodPost && r.URL.Path == collection:
+		if f.lease != nil {
+			http.Error(w, "already exists", http.StatusConflict)
+			return
+		}
+		f.store(w, r, http.StatusCreated)
+
+	case r.Method == http.MethodPut && r.URL.Path == collection+"/cron-connector":
+		if f.beforeUpdate != nil {
+			f.beforeUpdate()
+		}
+
+		var lease kubernetesLease
+		json.NewDecoder(r.Body).Decode(&lease)
+		if lease.Metadata.ResourceVersion != strconv.Itoa(f.resourceVersion) {
+			http.Error(w, "conflict", http.StatusConflict)
+			return
+		}
+		f.lease = &lease
+		f.resourceVersion++
+		f.lease.Metadata.ResourceVersion = strconv.Itoa(f.resourceVersion)
+		json.NewEncoder(w).Encode(f.lease)
+
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+func (f *fakeLeaseAPI) store(w http.ResponseWriter, r *http.Request, status int) {
+	var lease kubernetesLease
+	if err := json.NewDecoder(r.Body).Decode(&lease); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.resourceVersion++
+	lease.Metadata.ResourceVersion = strconv.Itoa(f.resourceVersion)
+	f.lease = &lease
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(f.lease)
+}
+
+func TestLeaderElection_Failover(t *testing.T) {
+	lease := NewFileLease(filepath.Join(t.TempDir(), "leader.lease"))
+
+	ctxA, cancelA := context.WithCancel(context.Background())
+	defer cancelA()
+	ctxB, cancelB := context.WithCancel(context.Background())
+
+	a := NewLeaderElection(lease, "a", 300*time.Millisecond)
+	b := NewLeaderElection(lease, "b", 300*time.Millisecond)
+
+	doneA := make(chan struct{})
+	go func() {
+		a.Run(ctxA)
+		close(doneA)
+	}()
+
+	waitFor(t, "a to lead", a.IsLeader)
+
+	doneB := make(chan struct{})
+	go func() {
+		b.Run(ctxB)
+		close(doneB)
+	}()
+	defer func() {
+		cancelB()
+		<-doneB
+	}()
+
+	time.Sleep(300 * time.Millisecond)
+	if !a.IsLeader() || b.IsLeader() {
+		t.Fatalf("expected only a to lead, a: %v, b: %v", a.IsLeader(), b.IsLeader())
+	}
+
+	cancelA()
+	<-doneA
+
+	waitFor(t, "b to take over", b.IsLeader)
+	if a.IsLeader() {
+		t.Error("expected a to have stepped down")
+	}
+}
+
+// hangingLease is acquired once, then each attempt to renew it hangs
+// until its context is done
+type hangingLease struct {
+	calls    atomic.Int32
+	returned chan time.Time
+}
+
+func (h *hangingLease) Acquire(ctx context.Context, identity string, duration time.Duration) (bool, error) {
+	if h.calls.Add(1) == 1 {
+		return true, nil
+	}
+
+	<-ctx.Done()
+	select {
+	case h.returned <- time.Now():
+	default:
+	}
+	return false, ctx.Err()
+}
+
+func (h *hangingLease) Release(ctx context.Context, identity string) error {
+	return nil
+}
+
+func TestLeaderElection_RenewalHangs(t *testing.T) {
+	lease := &hangingLease{returned: make(chan time.Time, 1)}
+	l := NewLeaderElection(lease, "a", 600*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	start := time.Now()
+	go l.Run(ctx)
+
+	waitFor(t, "a to lead", l.IsLeader)
+	waitFor(t, "a to step down", func() bool { return !l.IsLeader() })
+
+	// Another replica can take the lease once it expires, by then this
+	// replica must have stopped leading and given up on the renewal
+	if elapsed := time.Since(start); elapsed >= l.duration {
+		t.Errorf("expected to step down before the lease expired, took: %s", elapsed)
+	}
+
+	select {
+	case returned := <-lease.returned:
+		if elapsed := returned.Sub(start); elapsed >= l.duration {
+			t.Errorf("expected the renewal to be cancelled before the lease expired, took: %s", elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the renewal to be cancelled")
+	}
+}
+
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return
+		}
+	}
+
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestCronJob_StandbyDoesNotRun(t *testing.T) {
+	var calls atomic.Int32
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}, 1)
+
+	var leader atomic.Bool
+	s := NewScheduler(WithLeader(leader.Load))
+
+	annotations := map[string]string{"topic": "cron-function"}
+	job := &cronJob{
+		function: CronFunction{
+			FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+			Name:      "nightly",
+			Namespace: "openfaas-fn",
+			Schedule:  "0 2 * * *",
+		},
+		invoker:   invoker,
+		scheduler: s,
+	}
+
+	job.run(time.Now())
+	if got := calls.Load(); got != 0 {
+		t.Fatalf("expected the standby not to invoke the function, got %d invocations", got)
+	}
+
+	leader.Store(true)
+	job.run(time.Now())
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected the leader to invoke the function, got %d invocations", got)
+	}
+}
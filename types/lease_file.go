@@ -0,0 +1,130 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// staleLockAge is how old a lock file must be before it is assumed to
+// have been left behind by a replica which crashed while holding it
+const staleLockAge = time.Second * 10
+
+// FileLease is a Lease kept in a file, for replicas which share a
+// filesystem, such as in tests or on a single host
+type FileLease struct {
+	path string
+}
+
+// fileLeaseRecord is the content of a FileLease's file
+type fileLeaseRecord struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// NewFileLease returns a Lease kept in the file at path
+func NewFileLease(path string) *FileLease {
+	return &FileLease{path: path}
+}
+
+// Acquire takes or renews the lease for identity
+func (f *FileLease) Acquire(ctx context.Context, identity string, duration time.Duration) (bool, error) {
+	acquired := false
+
+	err := f.withLock(ctx, func(record fileLeaseRecord) (*fileLeaseRecord, error) {
+		if record.Holder != identity && len(record.Holder) > 0 && time.Now().Before(record.Expires) {
+			return nil, nil
+		}
+
+		acquired = true
+		return &fileLeaseRecord{
+			Holder:  identity,
+			Expires: time.Now().Add(duration),
+		}, nil
+	})
+
+	return acquired, err
+}
+
+// Release frees the lease if identity holds it
+func (f *FileLease) Release(ctx context.Context, identity string) error {
+	return f.withLock(ctx, func(record fileLeaseRecord) (*fileLeaseRecord, error) {
+		if record.Holder != identity {
+			return nil, nil
+		}
+
+		return &fileLeaseRecord{}, nil
+	})
+}
+
+// withLock reads the lease while holding a lock file, and writes the
+// record returned by update unless it is nil
+func (f *FileLease) withLock(ctx context.Context, update func(fileLeaseRecord) (*fileLeaseRecord, error)) error {
+	unlock, err := f.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var record fileLeaseRecord
+	data, err := os.ReadFile(f.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &record); err != nil {
+			return fmt.Errorf("unable to parse lease %s: %w", f.path, err)
+		}
+	}
+
+	updated, err := update(record)
+	if err != nil || updated == nil {
+		return err
+	}
+
+	data, err = json.Marshal(updated)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(f.path, data)
+}
+
+// lock creates the lock file next to the lease, creating a file with
+// O_EXCL is atomic on every platform
+func (f *FileLease) lock(ctx context.Context) (func(), error) {
+	lockPath := f.path + ".lock"
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0700); err != nil {
+		return nil, err
+	}
+
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+}
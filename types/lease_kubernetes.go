@@ -0,0 +1,282 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// serviceAccountPath holds the credentials Kubernetes gives to each pod
+const serviceAccountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// microTimeFormat is the format of times in a Lease's spec
+const microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// KubernetesLease is a Lease kept in a coordination.k8s.io/v1 Lease
+// object, which is read and written through the Kubernetes API. The
+// connector's service account needs permission to get, create and
+// update leases in the namespace.
+type KubernetesLease struct {
+	client    *http.Client
+	apiURL    string
+	tokenPath string
+
+	namespace string
+	name      string
+}
+
+// kubernetesLease is the subset of a Lease object which is used
+type kubernetesLease struct {
+	APIVersion string                  `json:"apiVersion"`
+	Kind       string                  `json:"kind"`
+	Metadata   kubernetesLeaseMetadata `json:"metadata"`
+	Spec       kubernetesLeaseSpec     `json:"spec"`
+}
+
+type kubernetesLeaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type kubernetesLeaseSpec struct {
+	HolderIdentity       *string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds *int32  `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          *string `json:"acquireTime,omitempty"`
+	RenewTime            *string `json:"renewTime,omitempty"`
+	LeaseTransitions     *int32  `json:"leaseTransitions,omitempty"`
+}
+
+// NewKubernetesLease returns a Lease kept in the Lease object called name,
+// using the in-cluster credentials of the pod. When namespace is empty,
+// the namespace of the pod is used.
+func NewKubernetesLease(namespace, name string) (*KubernetesLease, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if len(host) == 0 || len(port) == 0 {
+		return nil, fmt.Errorf("not running in a Kubernetes cluster, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT must be set")
+	}
+
+	ca, err := os.ReadFile(filepath.Join(serviceAccountPath, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("unable to parse the cluster's CA certificate")
+	}
+
+	if len(namespace) == 0 {
+		data, err := os.ReadFile(filepath.Join(serviceAccountPath, "namespace"))
+		if err != nil {
+			return nil, err
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+
+	client := &http.Client{
+		Timeout: time.Second * 10,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+
+	return newKubernetesLease(client, "https://"+net.JoinHostPort(host, port), filepath.Join(serviceAccountPath, "token"), namespace, name), nil
+}
+
+func newKubernetesLease(client *http.Client, apiURL, tokenPath, namespace, name string) *KubernetesLease {
+	return &KubernetesLease{
+		client:    client,
+		apiURL:    apiURL,
+		tokenPath: tokenPath,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+// Acquire takes or renews the lease for identity. Updates carry the
+// resourceVersion which was read, so when two replicas race for the
+// lease only one of them succeeds.
+func (k *KubernetesLease) Acquire(ctx context.Context, identity string, duration time.Duration) (bool, error) {
+	lease, found, err := k.get(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	nowString := now.UTC().Format(microTimeFormat)
+	seconds := int32(duration.Seconds())
+
+	if !found {
+		transitions := int32(0)
+		lease = &kubernetesLease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   kubernetesLeaseMetadata{Name: k.name, Namespace: k.namespace},
+			Spec: kubernetesLeaseSpec{
+				HolderIdentity:       &identity,
+				LeaseDurationSeconds: &seconds,
+				AcquireTime:          &nowString,
+				RenewTime:            &nowString,
+				LeaseTransitions:     &transitions,
+			},
+		}
+
+		return k.write(ctx, http.MethodPost, k.collectionURL(), lease)
+	}
+
+	holder := ""
+	if lease.Spec.HolderIdentity != nil {
+		holder = *lease.Spec.HolderIdentity
+	}
+
+	if holder != identity {
+		if len(holder) > 0 && now.Before(lease.expires()) {
+			return false, nil
+		}
+
+		transitions := int32(1)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions = *lease.Spec.LeaseTransitions + 1
+		}
+		lease.Spec.HolderIdentity = &identity
+		lease.Spec.AcquireTime = &nowString
+		lease.Spec.LeaseTransitions = &transitions
+	}
+
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.RenewTime = &nowString
+
+	return k.write(ctx, http.MethodPut, k.objectURL(), lease)
+}
+
+// Release frees the lease if identity holds it
+func (k *KubernetesLease) Release(ctx context.Context, identity string) error {
+	lease, found, err := k.get(ctx)
+	if err != nil || !found {
+		return err
+	}
+
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != identity {
+		return nil
+	}
+
+	empty := ""
+	lease.Spec.HolderIdentity = &empty
+
+	_, err = k.write(ctx, http.MethodPut, k.objectURL(), lease)
+	return err
+}
+
+// expires returns the time the lease runs out unless it is renewed
+func (l *kubernetesLease) expires() time.Time {
+	if l.Spec.RenewTime == nil || l.Spec.LeaseDurationSeconds == nil {
+		return time.Time{}
+	}
+
+	renewed, err := time.Parse(time.RFC3339Nano, *l.Spec.RenewTime)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return renewed.Add(time.Duration(*l.Spec.LeaseDurationSeconds) * time.Second)
+}
+
+func (k *KubernetesLease) collectionURL() string {
+	return fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", k.apiURL, k.namespace)
+}
+
+func (k *KubernetesLease) objectURL() string {
+	return k.collectionURL() + "/" + k.name
+}
+
+// get reads the lease, found is false if it does not exist yet
+func (k *KubernetesLease) get(ctx context.Context) (*kubernetesLease, bool, error) {
+	res, err := k.do(ctx, http.MethodGet, k.objectURL(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, false, nil
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, false, unexpectedStatus(res)
+	}
+
+	lease := &kubernetesLease{}
+	if err := json.NewDecoder(res.Body).Decode(lease); err != nil {
+		return nil, false, fmt.Errorf("unable to parse lease %s/%s: %w", k.namespace, k.name, err)
+	}
+
+	return lease, true, nil
+}
+
+// write creates or updates the lease, it returns false without an error
+// if another replica wrote the lease first
+func (k *KubernetesLease) write(ctx context.Context, method, url string, lease *kubernetesLease) (bool, error) {
+	body, err := json.Marshal(lease)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := k.do(ctx, method, url, body)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	}
+
+	return false, unexpectedStatus(res)
+}
+
+func (k *KubernetesLease) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// The token is read for each request, as Kubernetes rotates it
+	token, err := os.ReadFile(k.tokenPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return k.client.Do(req)
+}
+
+func unexpectedStatus(res *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return fmt.Errorf("unexpected status from the Kubernetes API: %d %s", res.StatusCode, strings.TrimSpace(string(body)))
+}
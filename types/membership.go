@@ -0,0 +1,117 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMembership keeps one file per member in a directory, for replicas
+// which share a volume. Each replica touches its file whenever the members
+// are read, and members whose file has not been touched within ttl are
+// assumed to have stopped.
+type FileMembership struct {
+	dir      string
+	identity string
+	ttl      time.Duration
+}
+
+// NewFileMembership returns a Membership kept in dir, which identity joins
+func NewFileMembership(dir, identity string, ttl time.Duration) *FileMembership {
+	return &FileMembership{
+		dir:      dir,
+		identity: identity,
+		ttl:      ttl,
+	}
+}
+
+// Members renews this replica's file, then returns the members whose
+// files are current
+func (f *FileMembership) Members(ctx context.Context) ([]string, error) {
+	if err := os.MkdirAll(f.dir, 0700); err != nil {
+		return nil, err
+	}
+
+	if err := f.touch(); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	members := []string{}
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if entry.Name() != filepath.Base(f.path()) && time.Since(info.ModTime()) > f.ttl {
+			continue
+		}
+
+		member, err := url.PathUnescape(entry.Name())
+		if err != nil {
+			continue
+		}
+		members = append(members, member)
+	}
+
+	return members, nil
+}
+
+// Close removes this replica's file, so that the others take over its
+// functions without waiting for it to expire
+func (f *FileMembership) Close() error {
+	if err := os.Remove(f.path()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// touch creates this replica's file, or updates its modification time
+func (f *FileMembership) touch() error {
+	file, err := os.OpenFile(f.path(), os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return os.Chtimes(f.path(), now, now)
+}
+
+func (f *FileMembership) path() string {
+	return filepath.Join(f.dir, url.PathEscape(f.identity))
+}
+
+// DNSMembership finds the members by looking up the addresses of a host,
+// such as a headless Kubernetes Service in front of the connector, where
+// each replica is identified by its pod IP
+type DNSMembership struct {
+	host     string
+	resolver *net.Resolver
+}
+
+// NewDNSMembership returns a Membership of the addresses of host
+func NewDNSMembership(host string) *DNSMembership {
+	return &DNSMembership{
+		host:     host,
+		resolver: net.DefaultResolver,
+	}
+}
+
+// Members returns the addresses host resolves to
+func (d *DNSMembership) Members(ctx context.Context) ([]string, error) {
+	return d.resolver.LookupHost(ctx, d.host)
+}
@@ -0,0 +1,160 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"bytes"
+	"crypto/sha256"
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"text/template"
+	"time"
+)
+
+// PayloadData is made available to payload templates
+type PayloadData struct {
+	// ScheduledTime is the time the run was planned for, in the
+	// function's timezone
+	ScheduledTime time.Time
+
+	// ActualTime is the time the run was dispatched, in the
+	// function's timezone
+	ActualTime time.Time
+
+	Function  string
+	Namespace string
+	Schedule  string
+	RunID     string
+}
+
+var payloadFuncs = template.FuncMap{
+	"duration": time.ParseDuration,
+	"json": func(v interface{}) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+}
+
+// readPayload returns the static request body set by the payload or
+// payload_base64 annotation, or nil when neither is set
+func readPayload(annotations map[string]string) ([]byte, error) {
+	inline, hasInline := annotations["payload"]
+	encoded, hasEncoded := annotations["payload_base64"]
+
+	if hasInline && hasEncoded {
+		return nil, fmt.Errorf("only one of payload and payload_base64 can be set")
+	}
+
+	if _, hasTemplate := annotations["payload_template"]; hasTemplate && (hasInline || hasEncoded) {
+		return nil, fmt.Errorf("payload_template cannot be combined with payload or payload_base64")
+	}
+
+	if hasEncoded {
+		payload, err := b64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("payload_base64 is not valid base64: %w", err)
+		}
+		return payload, nil
+	}
+
+	if hasInline {
+		return []byte(inline), nil
+	}
+
+	return nil, nil
+}
+
+// readPayloadTemplate parses the payload_template annotation and renders
+// it once with sample data, so that mistakes are found before the first run
+func readPayloadTemplate(annotations map[string]string) (*template.Template, error) {
+	text, ok := annotations["payload_template"]
+	if !ok {
+		return nil, nil
+	}
+
+	tmpl, err := template.New("payload_template").
+		Option("missingkey=error").
+		Funcs(payloadFuncs).
+		Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sample := PayloadData{
+		ScheduledTime: now,
+		ActualTime:    now,
+		Function:      "function",
+		Namespace:     "namespace",
+		Schedule:      "* * * * *",
+		RunID:         newRunID(),
+	}
+	if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+// renderPayload returns the request body for run
+func (c *CronFunction) renderPayload(run Run) ([]byte, error) {
+	if c.PayloadTemplate == nil {
+		return c.Payload, nil
+	}
+
+	scheduled, started := run.Scheduled, run.Started
+	if len(c.Timezone) > 0 {
+		if location, err := LoadTimezone(c.Timezone); err == nil {
+			scheduled, started = scheduled.In(location), started.In(location)
+		}
+	}
+
+	data := PayloadData{
+		ScheduledTime: scheduled,
+		ActualTime:    started,
+		Function:      c.Name,
+		Namespace:     c.Namespace,
+		Schedule:      c.Schedule,
+		RunID:         run.ID,
+	}
+
+	var out bytes.Buffer
+	if err := c.PayloadTemplate.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("unable to render payload_template: %w", err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// readContentType returns the content_type annotation, after checking
+// that it is a valid media type
+func readContentType(annotations map[string]string) (string, error) {
+	contentType, ok := annotations["content_type"]
+	if !ok {
+		return "", nil
+	}
+
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		return "", fmt.Errorf("content_type is not a valid media type: %w", err)
+	}
+
+	return contentType, nil
+}
+
+// PayloadSummary describes the static payload by its size and hash, so
+// that it can be logged without printing its contents
+func (c *CronFunction) PayloadSummary() string {
+	if c.PayloadTemplate != nil {
+		text := []byte((*c.FuncData.Annotations)["payload_template"])
+		return fmt.Sprintf("payload_template: %d bytes sha256:%x", len(text), sha256.Sum256(text))
+	}
+
+	if c.Payload == nil {
+		return "no payload"
+	}
+
+	return fmt.Sprintf("payload: %d bytes sha256:%x", len(c.Payload), sha256.Sum256(c.Payload))
+}
@@ -0,0 +1,166 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestToCronFunctions_Payload(t *testing.T) {
+	testcases := []struct {
+		Name        string
+		Annotations map[string]string
+		Want        string
+		WantErr     bool
+	}{
+		{Name: "no payload", Annotations: map[string]string{}},
+		{Name: "inline", Annotations: map[string]string{"payload": `{"mode":"full"}`}, Want: `{"mode":"full"}`},
+		{Name: "base64", Annotations: map[string]string{"payload_base64": "aGVsbG8="}, Want: "hello"},
+		{Name: "invalid base64", Annotations: map[string]string{"payload_base64": "not base64!"}, WantErr: true},
+		{Name: "both set", Annotations: map[string]string{"payload": "a", "payload_base64": "YQ=="}, WantErr: true},
+		{Name: "invalid content type", Annotations: map[string]string{"content_type": "application/"}, WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cfs := testCronFunctions(t, tc.WantErr, Defaults{}, tc.Annotations)
+			if tc.WantErr {
+				return
+			}
+
+			if string(cfs[0].Payload) != tc.Want {
+				t.Errorf("expected: %q, got: %q", tc.Want, string(cfs[0].Payload))
+			}
+		})
+	}
+}
+
+func TestInvokeFunction_Payload(t *testing.T) {
+	var gotBody, gotContentType string
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotContentType = r.Header.Get("Content-Type")
+	}, 1)
+	invoker.ContentType = "text/plain"
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:    ptypes.FunctionStatus{Annotations: &annotations},
+		Name:        "job",
+		Namespace:   "openfaas-fn",
+		Payload:     []byte(`{"mode":"full"}`),
+		ContentType: "application/json",
+	}
+
+	if _, err := c.InvokeFunction(context.Background(), invoker, NewRun(time.Now())); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if gotBody != `{"mode":"full"}` {
+		t.Errorf("expected body: %s, got: %s", `{"mode":"full"}`, gotBody)
+	}
+	if gotContentType != "application/json" {
+		t.Errorf("expected content type: application/json, got: %s", gotContentType)
+	}
+
+	c.Payload = nil
+	c.ContentType = ""
+	<-invoker.Responses
+
+	if _, err := c.InvokeFunction(context.Background(), invoker, NewRun(time.Now())); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if gotBody != "" {
+		t.Errorf("expected no body, got: %s", gotBody)
+	}
+	if gotContentType != "text/plain" {
+		t.Errorf("expected the connector's content type, got: %s", gotContentType)
+	}
+}
+
+func TestPayloadSummary(t *testing.T) {
+	c := CronFunction{Payload: []byte("hello")}
+
+	summary := c.PayloadSummary()
+	if !strings.HasPrefix(summary, "payload: 5 bytes sha256:2cf24dba") {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+
+	if strings.Contains(summary, "hello") {
+		t.Error("summary should not contain the payload")
+	}
+}
+
+func TestToCronFunctions_PayloadTemplate(t *testing.T) {
+	testcases := []struct {
+		Name     string
+		Template string
+		WantErr  bool
+	}{
+		{Name: "valid", Template: `{"from": "{{ (.ScheduledTime.Add (duration "-1h")).Format "15:04" }}"}`},
+		{Name: "syntax error", Template: `{{ .ScheduledTime `, WantErr: true},
+		{Name: "unknown field", Template: `{{ .Tomorrow }}`, WantErr: true},
+		{Name: "unknown function", Template: `{{ yesterday }}`, WantErr: true},
+		{Name: "invalid duration", Template: `{{ .ScheduledTime.Add (duration "one hour") }}`, WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			testCronFunctions(t, tc.WantErr, Defaults{}, map[string]string{"payload_template": tc.Template})
+		})
+	}
+}
+
+func TestToCronFunctions_PayloadTemplateWithPayload(t *testing.T) {
+	annotations := map[string]string{
+		"topic":            "cron-function",
+		"schedule":         "0 * * * *",
+		"payload":          "static",
+		"payload_template": "{{ .RunID }}",
+	}
+	f := ptypes.FunctionStatus{Name: "report", Annotations: &annotations}
+
+	if _, err := ToCronFunctions(f, "openfaas-fn", "cron-function", Defaults{}); err == nil {
+		t.Error("expected an error when both payload and payload_template are set")
+	}
+}
+
+func TestRenderPayload(t *testing.T) {
+	annotations := map[string]string{
+		"topic":             "cron-function",
+		"schedule":          "0 * * * *",
+		"schedule_timezone": "Europe/Paris",
+		"payload_template":  `{{ .Function }}.{{ .Namespace }} {{ (.ScheduledTime.Add (duration "-1h")).Format "2006-01-02T15:04Z07:00" }} {{ .Schedule }} {{ .RunID }}`,
+	}
+	f := ptypes.FunctionStatus{Name: "report", Annotations: &annotations}
+
+	cfs, err := ToCronFunctions(f, "openfaas-fn", "cron-function", Defaults{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	run := Run{
+		ID:        "abc123",
+		Scheduled: time.Date(2024, 6, 1, 8, 0, 0, 0, time.UTC),
+		Started:   time.Date(2024, 6, 1, 8, 0, 2, 0, time.UTC),
+	}
+
+	payload, err := cfs[0].renderPayload(run)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := "report.openfaas-fn 2024-06-01T09:00+02:00 0 * * * * abc123"
+	if string(payload) != want {
+		t.Errorf("expected: %q, got: %q", want, string(payload))
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// allowedMethods can be given in the method annotation
+var allowedMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// readMethod returns the HTTP method from the method annotation,
+// defaulting to POST
+func readMethod(annotations map[string]string) (string, error) {
+	method, ok := annotations["method"]
+	if !ok {
+		return http.MethodPost, nil
+	}
+
+	method = strings.ToUpper(method)
+	if !slices.Contains(allowedMethods, method) {
+		return "", fmt.Errorf("method must be one of %s", strings.Join(allowedMethods, ", "))
+	}
+
+	return method, nil
+}
+
+// readPath returns the path annotation, which is appended to the URL
+// of the function, with a leading slash
+func readPath(annotations map[string]string) (string, error) {
+	p, ok := annotations["path"]
+	if !ok || len(p) == 0 {
+		return "", nil
+	}
+
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	u, err := url.Parse(p)
+	if err != nil {
+		return "", fmt.Errorf("path is not valid: %w", err)
+	}
+
+	if u.Path != p || len(u.RawQuery) > 0 || len(u.Fragment) > 0 {
+		return "", fmt.Errorf("path must not contain a query, fragment or escaped characters: %q", p)
+	}
+
+	for _, segment := range strings.Split(p, "/") {
+		if segment == "." || segment == ".." {
+			return "", fmt.Errorf("path must not contain . or .. segments: %q", p)
+		}
+	}
+
+	return p, nil
+}
+
+// readQuery parses the query annotation, given as a URL query
+// string such as "mode=full&dry-run=true"
+func readQuery(annotations map[string]string) (url.Values, error) {
+	q, ok := annotations["query"]
+	if !ok || len(q) == 0 {
+		return nil, nil
+	}
+
+	values, err := url.ParseQuery(strings.TrimPrefix(q, "?"))
+	if err != nil {
+		return nil, fmt.Errorf("query is not valid: %w", err)
+	}
+
+	return values, nil
+}
+
+// requestURL returns the URL to invoke the function on, via gatewayURL
+func (c *CronFunction) requestURL(gatewayURL string) string {
+	u := fmt.Sprintf("%s/%s%s", gatewayURL, c.String(), c.Path)
+
+	if len(c.Query) > 0 {
+		u += "?" + c.Query.Encode()
+	}
+
+	return u
+}
@@ -0,0 +1,72 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestToCronFunctions_Request(t *testing.T) {
+	testcases := []struct {
+		Name        string
+		Annotations map[string]string
+		WantErr     bool
+	}{
+		{Name: "defaults", Annotations: map[string]string{}},
+		{Name: "get with path and query", Annotations: map[string]string{"method": "get", "path": "/cleanup", "query": "mode=full"}},
+		{Name: "path without leading slash", Annotations: map[string]string{"path": "rebuild/all"}},
+		{Name: "unknown method", Annotations: map[string]string{"method": "CONNECT"}, WantErr: true},
+		{Name: "get with payload", Annotations: map[string]string{"method": "GET", "payload": "{}"}, WantErr: true},
+		{Name: "path with query", Annotations: map[string]string{"path": "/cleanup?mode=full"}, WantErr: true},
+		{Name: "path with fragment", Annotations: map[string]string{"path": "/cleanup#top"}, WantErr: true},
+		{Name: "path with dot dot", Annotations: map[string]string{"path": "/../other-function"}, WantErr: true},
+		{Name: "invalid query", Annotations: map[string]string{"query": "mode=%zz"}, WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			testCronFunctions(t, tc.WantErr, Defaults{}, tc.Annotations)
+		})
+	}
+}
+
+func TestInvokeFunction_MethodPathAndQuery(t *testing.T) {
+	var gotMethod, gotURI string
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotURI = r.RequestURI
+	}, 1)
+
+	annotations := map[string]string{
+		"topic":    "cron-function",
+		"schedule": "* * * * *",
+		"method":   "GET",
+		"path":     "cleanup",
+		"query":    "mode=full",
+	}
+	f := ptypes.FunctionStatus{Name: "router", Annotations: &annotations}
+
+	cfs, err := ToCronFunctions(f, "openfaas-fn", "cron-function", Defaults{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := cfs[0].InvokeFunction(context.Background(), invoker, NewRun(time.Now())); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if gotMethod != http.MethodGet {
+		t.Errorf("expected method: %s, got: %s", http.MethodGet, gotMethod)
+	}
+
+	want := "/function/router.openfaas-fn/cleanup?mode=full"
+	if gotURI != want {
+		t.Errorf("expected URI: %s, got: %s", want, gotURI)
+	}
+}
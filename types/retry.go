@@ -0,0 +1,138 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = time.Second * 30
+
+	// maxRetryAttempts keeps a misconfigured function from
+	// being retried indefinitely
+	maxRetryAttempts = 20
+)
+
+// RetryPolicy decides if and when a failed invocation is tried again
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+
+	// Backoff is the wait before the second attempt, it doubles
+	// for each attempt after that
+	Backoff time.Duration
+
+	// MaxBackoff is the longest wait between attempts
+	MaxBackoff time.Duration
+
+	// StatusCodes are retried, when empty 429 and all 5xx status
+	// codes are retried
+	StatusCodes []int
+}
+
+// readRetryPolicy parses the retry_max_attempts, retry_backoff,
+// retry_max_backoff and retry_status_codes annotations
+func readRetryPolicy(annotations map[string]string) (RetryPolicy, error) {
+	policy := RetryPolicy{
+		MaxAttempts: 1,
+		Backoff:     defaultRetryBackoff,
+		MaxBackoff:  defaultRetryMaxBackoff,
+	}
+
+	if v, ok := annotations["retry_max_attempts"]; ok {
+		attempts, err := strconv.Atoi(v)
+		if err != nil || attempts < 1 || attempts > maxRetryAttempts {
+			return RetryPolicy{}, fmt.Errorf("retry_max_attempts must be between 1 and %d: %q", maxRetryAttempts, v)
+		}
+		policy.MaxAttempts = attempts
+	}
+
+	if v, ok := annotations["retry_backoff"]; ok {
+		backoff, err := time.ParseDuration(v)
+		if err != nil || backoff <= 0 {
+			return RetryPolicy{}, fmt.Errorf("retry_backoff must be a positive duration: %q", v)
+		}
+		policy.Backoff = backoff
+	}
+
+	if v, ok := annotations["retry_max_backoff"]; ok {
+		maxBackoff, err := time.ParseDuration(v)
+		if err != nil || maxBackoff <= 0 {
+			return RetryPolicy{}, fmt.Errorf("retry_max_backoff must be a positive duration: %q", v)
+		}
+		policy.MaxBackoff = maxBackoff
+	}
+
+	if policy.MaxBackoff < policy.Backoff {
+		return RetryPolicy{}, fmt.Errorf("retry_max_backoff must not be less than retry_backoff")
+	}
+
+	if v, ok := annotations["retry_status_codes"]; ok {
+		for _, field := range strings.Split(v, ",") {
+			code, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || code < 100 || code > 599 {
+				return RetryPolicy{}, fmt.Errorf("retry_status_codes must be a list of HTTP status codes: %q", v)
+			}
+			policy.StatusCodes = append(policy.StatusCodes, code)
+		}
+	}
+
+	return policy, nil
+}
+
+// retryable returns true if an attempt which failed with err, or
+// returned status, should be tried again
+func (p RetryPolicy) retryable(status int, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrInvalidRequest)
+	}
+
+	if len(p.StatusCodes) > 0 {
+		return slices.Contains(p.StatusCodes, status)
+	}
+
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// wait returns how long to wait after the given attempt failed. A
+// Retry-After header is followed, but never beyond MaxBackoff.
+func (p RetryPolicy) wait(attempt int, header http.Header) time.Duration {
+	if retryAfter, ok := parseRetryAfter(header); ok {
+		return min(retryAfter, p.MaxBackoff)
+	}
+
+	wait := p.Backoff
+	for i := 1; i < attempt && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+
+	return min(wait, p.MaxBackoff)
+}
+
+// parseRetryAfter reads the Retry-After header, which is either a
+// number of seconds or an HTTP date
+func parseRetryAfter(header http.Header) (time.Duration, bool) {
+	v := header.Get("Retry-After")
+	if len(v) == 0 {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if at, err := http.ParseTime(v); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
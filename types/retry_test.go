@@ -0,0 +1,259 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/openfaas/connector-sdk/types"
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestReadRetryPolicy(t *testing.T) {
+	testcases := []struct {
+		Name        string
+		Annotations map[string]string
+		Want        RetryPolicy
+		WantErr     bool
+	}{
+		{
+			Name:        "defaults",
+			Annotations: map[string]string{},
+			Want:        RetryPolicy{MaxAttempts: 1, Backoff: time.Second, MaxBackoff: 30 * time.Second},
+		},
+		{
+			Name: "all set",
+			Annotations: map[string]string{
+				"retry_max_attempts": "5",
+				"retry_backoff":      "2s",
+				"retry_max_backoff":  "1m",
+				"retry_status_codes": "502, 503",
+			},
+			Want: RetryPolicy{MaxAttempts: 5, Backoff: 2 * time.Second, MaxBackoff: time.Minute, StatusCodes: []int{502, 503}},
+		},
+		{Name: "zero attempts", Annotations: map[string]string{"retry_max_attempts": "0"}, WantErr: true},
+		{Name: "too many attempts", Annotations: map[string]string{"retry_max_attempts": "1000"}, WantErr: true},
+		{Name: "invalid backoff", Annotations: map[string]string{"retry_backoff": "soon"}, WantErr: true},
+		{Name: "max below backoff", Annotations: map[string]string{"retry_backoff": "1m", "retry_max_backoff": "1s"}, WantErr: true},
+		{Name: "invalid status code", Annotations: map[string]string{"retry_status_codes": "5xx"}, WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			got, err := readRetryPolicy(tc.Annotations)
+			if tc.WantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got.MaxAttempts != tc.Want.MaxAttempts || got.Backoff != tc.Want.Backoff || got.MaxBackoff != tc.Want.MaxBackoff {
+				t.Errorf("expected: %+v, got: %+v", tc.Want, got)
+			}
+			if len(got.StatusCodes) != len(tc.Want.StatusCodes) {
+				t.Errorf("expected status codes: %v, got: %v", tc.Want.StatusCodes, got.StatusCodes)
+			}
+		})
+	}
+}
+
+func TestRetryPolicy_Retryable(t *testing.T) {
+	defaults := RetryPolicy{}
+	custom := RetryPolicy{StatusCodes: []int{409}}
+
+	testcases := []struct {
+		Policy RetryPolicy
+		Status int
+		Err    error
+		Want   bool
+	}{
+		{Policy: defaults, Status: http.StatusOK, Want: false},
+		{Policy: defaults, Status: http.StatusNotFound, Want: false},
+		{Policy: defaults, Status: http.StatusTooManyRequests, Want: true},
+		{Policy: defaults, Status: http.StatusBadGateway, Want: true},
+		{Policy: defaults, Err: context.DeadlineExceeded, Want: true},
+		{Policy: defaults, Err: ErrInvalidRequest, Want: false},
+		{Policy: custom, Status: http.StatusBadGateway, Want: false},
+		{Policy: custom, Status: http.StatusConflict, Want: true},
+	}
+
+	for _, tc := range testcases {
+		if got := tc.Policy.retryable(tc.Status, tc.Err); got != tc.Want {
+			t.Errorf("status %d, error %v, expected: %v, got: %v", tc.Status, tc.Err, tc.Want, got)
+		}
+	}
+}
+
+func TestRetryPolicy_Wait(t *testing.T) {
+	p := RetryPolicy{Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if got := p.wait(attempt+1, nil); got != want {
+			t.Errorf("attempt %d expected: %s, got: %s", attempt+1, want, got)
+		}
+	}
+
+	if got := p.wait(1, http.Header{"Retry-After": {"3"}}); got != 3*time.Second {
+		t.Errorf("expected Retry-After to be followed, got: %s", got)
+	}
+
+	if got := p.wait(1, http.Header{"Retry-After": {"3600"}}); got != 5*time.Second {
+		t.Errorf("expected Retry-After to be capped, got: %s", got)
+	}
+}
+
+func TestInvokeFunction_Retries(t *testing.T) {
+	var calls atomic.Int32
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("done"))
+	}, 5)
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+		Name:      "flaky",
+		Namespace: "openfaas-fn",
+		Retry:     RetryPolicy{MaxAttempts: 5, Backoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	}
+
+	body, err := c.InvokeFunction(context.Background(), invoker, NewRun(time.Now()))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if body == nil || string(*body) != "done" {
+		t.Errorf("expected the body of the last attempt")
+	}
+
+	if calls.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls.Load())
+	}
+
+	close(invoker.Responses)
+	attempt := 0
+	for res := range invoker.Responses {
+		attempt++
+		run, ok := RunFromContext(res.Context)
+		if !ok || run.Attempt != attempt {
+			t.Errorf("expected response for attempt %d, got: %d", attempt, run.Attempt)
+		}
+	}
+	if attempt != 3 {
+		t.Errorf("expected a response for each attempt, got %d", attempt)
+	}
+}
+
+func TestInvokeFunction_NoRetryByDefault(t *testing.T) {
+	var calls atomic.Int32
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}, 5)
+
+	annotations := map[string]string{
+		"topic":    "cron-function",
+		"schedule": "* * * * *",
+	}
+	f := ptypes.FunctionStatus{Name: "flaky", Annotations: &annotations}
+
+	cfs, err := ToCronFunctions(f, "openfaas-fn", "cron-function", Defaults{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if _, err := cfs[0].InvokeFunction(context.Background(), invoker, NewRun(time.Now())); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls.Load())
+	}
+}
+
+func TestInvokeFunction_CancelledDuringBackoff(t *testing.T) {
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, 5)
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+		Name:      "flaky",
+		Namespace: "openfaas-fn",
+		Retry:     RetryPolicy{MaxAttempts: 3, Backoff: time.Hour, MaxBackoff: time.Hour},
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(50*time.Millisecond, func() { cancel(ErrRunReplaced) })
+
+	if _, err := c.InvokeFunction(ctx, invoker, NewRun(time.Now())); !errors.Is(err, ErrRunReplaced) {
+		t.Fatalf("expected the error to be %s, got: %v", ErrRunReplaced, err)
+	}
+
+	close(invoker.Responses)
+	responses := []types.InvokerResponse{}
+	for res := range invoker.Responses {
+		responses = append(responses, res)
+	}
+	if len(responses) != 2 {
+		t.Fatalf("expected a response for the failed and the cancelled attempt, got %d", len(responses))
+	}
+
+	last := responses[1]
+	if !errors.Is(last.Error, ErrRunReplaced) {
+		t.Errorf("expected the error to be %s, got: %v", ErrRunReplaced, last.Error)
+	}
+	if run, ok := RunFromContext(last.Context); !ok || run.Attempt != 2 {
+		t.Errorf("expected the response for attempt 2, got: %d", run.Attempt)
+	}
+}
+
+func TestInvokeFunction_InvalidRequest(t *testing.T) {
+	var calls atomic.Int32
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}, 5)
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+		Name:      "job",
+		Namespace: "openfaas-fn",
+		Method:    "NOT A METHOD",
+		Retry:     RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, MaxBackoff: time.Millisecond},
+	}
+
+	if _, err := c.InvokeFunction(context.Background(), invoker, NewRun(time.Now())); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("expected the error to be %s, got: %v", ErrInvalidRequest, err)
+	}
+
+	if calls.Load() != 0 {
+		t.Errorf("expected no calls to the gateway, got %d", calls.Load())
+	}
+
+	close(invoker.Responses)
+	count := 0
+	for res := range invoker.Responses {
+		count++
+		if res.Status != http.StatusInternalServerError {
+			t.Errorf("expected status %d, got: %d", http.StatusInternalServerError, res.Status)
+		}
+	}
+	if count != 1 {
+		t.Errorf("expected a single response, got %d", count)
+	}
+}
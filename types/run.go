@@ -0,0 +1,80 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// Run describes a single invocation of a CronFunction
+type Run struct {
+	// ID is unique to each run
+	ID string
+
+	// Function and Namespace identify the function the run is for
+	Function  string
+	Namespace string
+
+	// Scheduled is the time the run was planned for
+	Scheduled time.Time
+
+	// Started is the time the run was dispatched
+	Started time.Time
+
+	// Attempt counts the attempts to invoke the function for
+	// this run, starting at 1
+	Attempt int
+
+	// Manual is set for runs which were triggered on demand, rather
+	// than by the schedule
+	Manual bool
+}
+
+// NewRun returns a Run with a new ID, which was planned for scheduled and
+// is starting now
+func NewRun(scheduled time.Time) Run {
+	return Run{
+		ID:        newRunID(),
+		Scheduled: scheduled,
+		Started:   time.Now(),
+	}
+}
+
+func (r Run) trigger() string {
+	if r.Manual {
+		return "manual"
+	}
+
+	return "schedule"
+}
+
+func newRunID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
+
+type runContextKey struct{}
+
+// WithRun returns a copy of ctx which carries run
+func WithRun(ctx context.Context, run Run) context.Context {
+	return context.WithValue(ctx, runContextKey{}, run)
+}
+
+// RunFromContext returns the run carried by ctx, such as the Context
+// of an InvokerResponse
+func RunFromContext(ctx context.Context) (Run, bool) {
+	if ctx == nil {
+		return Run{}, false
+	}
+
+	run, ok := ctx.Value(runContextKey{}).(Run)
+	return run, ok
+}
@@ -0,0 +1,118 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrRunLockFailed is reported for runs which were not started because
+// the RunLock could not be reached
+var ErrRunLockFailed = errors.New("unable to claim run")
+
+// runLockTTL is how long a claim on a run is kept for, so that replicas
+// which fire the same run later, such as when catching up, still see it
+const runLockTTL = time.Hour * 24
+
+// RunLock lets each replica of the connector run every schedule, while
+// making sure that each run is only started by one of them
+type RunLock interface {
+	// TryLock claims the run identified by key for ttl, it returns
+	// true if this replica claimed it, and false if another replica
+	// already had
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
+
+// FileRunLock is a RunLock kept as one file per run in a directory, for
+// replicas which share a volume. The modification time of each file is
+// set to when the claim expires.
+type FileRunLock struct {
+	dir string
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+// NewFileRunLock returns a RunLock kept in dir
+func NewFileRunLock(dir string) *FileRunLock {
+	return &FileRunLock{dir: dir}
+}
+
+// TryLock claims the run identified by key, which must be usable as a
+// file name. Creating a file with O_EXCL is atomic, so only one replica
+// can claim each run.
+func (f *FileRunLock) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if err := os.MkdirAll(f.dir, 0700); err != nil {
+		return false, err
+	}
+
+	f.prune()
+
+	path := filepath.Join(f.dir, key)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := file.Close(); err != nil {
+		return false, err
+	}
+
+	expires := time.Now().Add(ttl)
+	if err := os.Chtimes(path, expires, expires); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// prune removes expired claims, at most once a minute
+func (f *FileRunLock) prune() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if time.Since(f.lastPrune) < time.Minute {
+		return
+	}
+	f.lastPrune = time.Now()
+
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		// Claims which were only just created have not had their
+		// expiry set yet
+		if err == nil && time.Since(info.ModTime()) > time.Minute {
+			os.Remove(filepath.Join(f.dir, entry.Name()))
+		}
+	}
+}
+
+// claim returns true if this replica should start the run of c which was
+// scheduled for run.Scheduled
+func (s *Scheduler) claim(c CronFunction, run Run) (bool, error) {
+	if s.runLock == nil {
+		return true, nil
+	}
+
+	ttl := runLockTTL
+	if c.StartingDeadline+time.Hour > ttl {
+		ttl = c.StartingDeadline + time.Hour
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	return s.runLock.TryLock(ctx, c.IdempotencyKey(run.Scheduled), ttl)
+}
@@ -0,0 +1,201 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestRunLock(t *testing.T) {
+	testcases := []struct {
+		Name string
+		Lock func(t *testing.T) RunLock
+	}{
+		{Name: "memory", Lock: func(t *testing.T) RunLock { return newMemoryRunLock() }},
+		{Name: "file", Lock: func(t *testing.T) RunLock { return NewFileRunLock(filepath.Join(t.TempDir(), "run_locks")) }},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			lock := tc.Lock(t)
+			ctx := context.Background()
+
+			var claimed atomic.Int32
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ok, err := lock.TryLock(ctx, "run-1", time.Hour)
+					if err != nil {
+						t.Errorf("unexpected error: %s", err)
+					}
+					if ok {
+						claimed.Add(1)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if got := claimed.Load(); got != 1 {
+				t.Errorf("expected the run to be claimed once, got: %d", got)
+			}
+
+			if ok, err := lock.TryLock(ctx, "run-2", time.Hour); err != nil || !ok {
+				t.Errorf("expected another run to be claimed, got: %v %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestMemoryRunLock_Expires(t *testing.T) {
+	lock := newMemoryRunLock()
+	ctx := context.Background()
+
+	lock.TryLock(ctx, "run-1", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	if ok, _ := lock.TryLock(ctx, "run-1", time.Hour); !ok {
+		t.Error("expected an expired claim to be claimed again")
+	}
+}
+
+func TestFileRunLock_Prunes(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "run_locks")
+	lock := NewFileRunLock(dir)
+	ctx := context.Background()
+
+	lock.TryLock(ctx, "expired", time.Hour)
+	lock.TryLock(ctx, "current", time.Hour)
+
+	past := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(filepath.Join(dir, "expired"), past, past)
+
+	lock.lastPrune = time.Time{}
+	lock.TryLock(ctx, "other", time.Hour)
+
+	if _, err := os.Stat(filepath.Join(dir, "expired")); !os.IsNotExist(err) {
+		t.Error("expected the expired claim to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "current")); err != nil {
+		t.Errorf("expected the current claim to be kept: %s", err)
+	}
+}
+
+func TestCronJob_RunLockDedupesReplicas(t *testing.T) {
+	var mu sync.Mutex
+	invoked := map[string]int{}
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		invoked[r.Header.Get(ScheduledTimeHeader)]++
+		mu.Unlock()
+	}, 10)
+
+	lock := NewFileRunLock(filepath.Join(t.TempDir(), "run_locks"))
+
+	annotations := map[string]string{"topic": "cron-function"}
+	c := CronFunction{
+		FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+		Name:      "nightly",
+		Namespace: "openfaas-fn",
+		Schedule:  "0 2 * * *",
+	}
+
+	replicas := []*cronJob{}
+	for i := 0; i < 3; i++ {
+		replicas = append(replicas, &cronJob{function: c, invoker: invoker, scheduler: NewScheduler(WithRunLock(lock))})
+	}
+
+	first := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+	second := first.Add(24 * time.Hour)
+
+	var wg sync.WaitGroup
+	for _, scheduled := range []time.Time{first, second} {
+		for _, job := range replicas {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				job.run(scheduled)
+			}()
+		}
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, scheduled := range []time.Time{first, second} {
+		key := scheduled.Format(time.RFC3339)
+		if invoked[key] != 1 {
+			t.Errorf("expected the run for %s to be invoked once, got: %d", key, invoked[key])
+		}
+	}
+}
+
+// memoryRunLock is a RunLock held in memory, for tests
+type memoryRunLock struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// newMemoryRunLock returns an empty memoryRunLock
+func newMemoryRunLock() *memoryRunLock {
+	return &memoryRunLock{
+		expires: make(map[string]time.Time),
+	}
+}
+
+// TryLock claims the run identified by key
+func (m *memoryRunLock) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for k, expires := range m.expires {
+		if now.After(expires) {
+			delete(m.expires, k)
+		}
+	}
+
+	if _, ok := m.expires[key]; ok {
+		return false, nil
+	}
+
+	m.expires[key] = now.Add(ttl)
+	return true, nil
+}
+
+func TestScheduler_RunLockRejectsEvery(t *testing.T) {
+	testcases := []struct {
+		Schedule string
+		WantErr  bool
+	}{
+		{Schedule: "@every 1h", WantErr: true},
+		{Schedule: "CRON_TZ=Europe/London @every 30m", WantErr: true},
+		{Schedule: "@hourly", WantErr: false},
+		{Schedule: "H * * * *", WantErr: false},
+	}
+
+	s := NewScheduler(WithRunLock(newMemoryRunLock()))
+
+	for _, tc := range testcases {
+		_, err := s.AddCronFunction(CronFunction{Name: "sync", Namespace: "openfaas-fn", Schedule: tc.Schedule, ScheduleFormat: StandardFormat}, nil)
+		if (err != nil) != tc.WantErr {
+			t.Errorf("%q expected error: %v, got: %v", tc.Schedule, tc.WantErr, err)
+		}
+	}
+
+	if _, err := NewScheduler().AddCronFunction(CronFunction{Name: "sync", Namespace: "openfaas-fn", Schedule: "@every 1h", ScheduleFormat: StandardFormat}, nil); err != nil {
+		t.Errorf("expected @every to be allowed without a run lock, got: %s", err)
+	}
+}
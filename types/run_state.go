@@ -0,0 +1,158 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// RunState records when each scheduled function last ran in a file on
+// local disk, so that runs missed while the connector was down can be
+// found when it starts again
+type RunState struct {
+	path string
+
+	mu       sync.Mutex
+	lastRuns map[string]time.Time
+
+	// flush is pending while there are changes which have not been
+	// saved yet
+	flush *time.Timer
+}
+
+// runStateFlushDelay is how long changes to the run state are batched
+// for before they are saved
+const runStateFlushDelay = time.Second
+
+// NewRunState loads the run state from path, a missing file gives an
+// empty state
+func NewRunState(path string) (*RunState, error) {
+	state := &RunState{
+		path:     path,
+		lastRuns: make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &state.lastRuns); err != nil {
+		return nil, fmt.Errorf("unable to parse run state %s: %w", path, err)
+	}
+
+	return state, nil
+}
+
+// LastRun returns the last time the function with key ran
+func (r *RunState) LastRun(key string) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.lastRuns[key]
+	return t, ok
+}
+
+// Record stores t as the last time the function with key ran, unless
+// a later time has already been recorded. Changes are saved in batches.
+func (r *RunState) Record(key string, t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if last, ok := r.lastRuns[key]; ok && !t.After(last) {
+		return
+	}
+
+	r.lastRuns[key] = t
+	r.scheduleFlush()
+}
+
+// Remove forgets the function with key
+func (r *RunState) Remove(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.lastRuns[key]; !ok {
+		return
+	}
+
+	delete(r.lastRuns, key)
+	r.scheduleFlush()
+}
+
+// Flush saves any changes which are pending
+func (r *RunState) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.flush == nil {
+		return nil
+	}
+	r.flush.Stop()
+	r.flush = nil
+
+	return r.save()
+}
+
+// Close saves any changes which are pending, it is called on shutdown
+func (r *RunState) Close() error {
+	return r.Flush()
+}
+
+// scheduleFlush saves the state after runStateFlushDelay, unless a
+// save is already pending. r.mu must be held.
+func (r *RunState) scheduleFlush() {
+	if r.flush != nil {
+		return
+	}
+
+	r.flush = time.AfterFunc(runStateFlushDelay, func() {
+		if err := r.Flush(); err != nil {
+			log.Printf("Error saving run state: %s", err)
+		}
+	})
+}
+
+// save writes the state to a temporary file and renames it over the
+// previous state, so that a crash cannot leave a partial file behind
+func (r *RunState) save() error {
+	data, err := json.Marshal(r.lastRuns)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(r.path, data)
+}
+
+// writeFileAtomic writes data to path via a temporary file
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -4,7 +4,10 @@
 package types
 
 import (
-	"log"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/openfaas/connector-sdk/types"
 	cron "github.com/robfig/cron/v3"
@@ -13,13 +16,117 @@ import (
 // EntryID type redifined for this package
 type EntryID cron.EntryID
 
+// ScheduleFormat selects the cron syntax a schedule is written in
+type ScheduleFormat string
+
+const (
+	// StandardFormat has five fields, from minute to day of week
+	StandardFormat ScheduleFormat = "standard"
+
+	// SecondsFormat adds a leading seconds field. The seconds field is
+	// optional, so five-field schedules keep their meaning.
+	SecondsFormat ScheduleFormat = "seconds"
+)
+
 var standardParser = cron.NewParser(
 	cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
 )
 
+var secondsParser = cron.NewParser(
+	cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor,
+)
+
+// ParseScheduleFormat returns the ScheduleFormat named by value, an
+// empty value selects StandardFormat
+func ParseScheduleFormat(value string) (ScheduleFormat, error) {
+	switch ScheduleFormat(value) {
+	case "", StandardFormat:
+		return StandardFormat, nil
+	case SecondsFormat:
+		return SecondsFormat, nil
+	}
+
+	return "", fmt.Errorf("unknown schedule format: %q, use %q or %q", value, StandardFormat, SecondsFormat)
+}
+
+func (f ScheduleFormat) parser() cron.Parser {
+	if f == SecondsFormat {
+		return secondsParser
+	}
+
+	return standardParser
+}
+
 // Scheduler is an interface which talks with cron scheduler
 type Scheduler struct {
 	main *cron.Cron
+
+	mu sync.Mutex
+
+	// active holds the runs which are in flight, by run ID
+	active map[string]*activeRun
+
+	// stopping is set by Stop, after which no runs are started
+	stopping bool
+
+	// state records when each function last ran, when nil runs
+	// missed while the connector was down are not caught up on
+	state *RunState
+
+	// pauses records the functions paused at runtime, when nil
+	// functions cannot be paused
+	pauses *PauseState
+
+	// isLeader returns false on replicas which are on standby, when
+	// nil this replica always runs functions
+	isLeader func() bool
+
+	// runLock is claimed before each run, so that only one replica
+	// starts it, when nil every run is started
+	runLock RunLock
+
+	// heartbeat is the Unix time in nanoseconds at which cron last
+	// ran the heartbeat job
+	heartbeat atomic.Int64
+}
+
+// heartbeatInterval is how often cron runs the heartbeat job, which shows
+// that it is still dispatching jobs
+const heartbeatInterval = time.Second * 10
+
+// SchedulerOption configures a Scheduler
+type SchedulerOption func(*Scheduler)
+
+// WithRunState records the last run of each function in state, and
+// catches up on missed runs when functions are added
+func WithRunState(state *RunState) SchedulerOption {
+	return func(s *Scheduler) {
+		s.state = state
+	}
+}
+
+// WithPauseState lets functions be paused and resumed at runtime,
+// recording which are paused in state
+func WithPauseState(state *PauseState) SchedulerOption {
+	return func(s *Scheduler) {
+		s.pauses = state
+	}
+}
+
+// WithLeader only runs functions on their schedule while isLeader
+// returns true, such as LeaderElection.IsLeader
+func WithLeader(isLeader func() bool) SchedulerOption {
+	return func(s *Scheduler) {
+		s.isLeader = isLeader
+	}
+}
+
+// WithRunLock claims each run in lock before starting it, so that
+// several replicas can run the same schedules
+func WithRunLock(lock RunLock) SchedulerOption {
+	return func(s *Scheduler) {
+		s.runLock = lock
+	}
 }
 
 // ScheduledFunction is a CronFunction that has been scheduled to run
@@ -30,54 +137,256 @@ type ScheduledFunction struct {
 
 	// Id is the entryid for the scheduled function
 	ID EntryID
+
+	// Discovered is when the function was added to the scheduler
+	Discovered time.Time
 }
 
 // ScheduledFunctions is an array of ScheduledFunction
 type ScheduledFunctions []ScheduledFunction
 
 // NewScheduler returns a scheduler
-func NewScheduler() *Scheduler {
-	return &Scheduler{
-		cron.New(cron.WithParser(standardParser)),
+func NewScheduler(opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		main:   cron.New(cron.WithParser(standardParser)),
+		active: make(map[string]*activeRun),
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Start a scheduler in new go routine
 func (s *Scheduler) Start() {
+	s.beat()
+	s.main.Schedule(cron.Every(heartbeatInterval), cron.FuncJob(s.beat))
 	s.main.Start()
 }
 
+// leading returns true if this replica should run functions on
+// their schedule
+func (s *Scheduler) leading() bool {
+	return s.isLeader == nil || s.isLeader()
+}
+
+func (s *Scheduler) beat() {
+	s.heartbeat.Store(time.Now().UnixNano())
+}
+
+// LastHeartbeat returns the last time cron dispatched its heartbeat job,
+// it is zero until the scheduler has been started
+func (s *Scheduler) LastHeartbeat() time.Time {
+	beat := s.heartbeat.Load()
+	if beat == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, beat)
+}
+
+// cronSchedule parses the function's schedule in its format and timezone
+func (c *CronFunction) cronSchedule() (cron.Schedule, error) {
+	resolved, err := c.ResolvedSchedule()
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := c.ScheduleFormat.parser().Parse(resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.Timezone) > 0 {
+		location, err := LoadTimezone(c.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		schedule = newZonedSchedule(schedule, location)
+	}
+
+	return schedule, nil
+}
+
+// parse returns the function's schedule, refusing schedules which cannot
+// be run by this scheduler
+func (s *Scheduler) parse(c CronFunction) (cron.Schedule, error) {
+	schedule, err := c.cronSchedule()
+	if err != nil {
+		return nil, err
+	}
+
+	// The runs of @every schedules are timed from when each replica
+	// started, so they never have the same key on two replicas
+	if _, ok := schedule.(cron.ConstantDelaySchedule); ok && s.runLock != nil {
+		return nil, fmt.Errorf("@every schedules cannot be used with a run lock, as each replica runs them at different times: %s", c.Schedule)
+	}
+
+	return schedule, nil
+}
+
 // AddCronFunction adds a function to cron
 func (s *Scheduler) AddCronFunction(c CronFunction, invoker *types.Invoker) (ScheduledFunction, error) {
-	eID, err := s.main.AddFunc(c.Schedule, func() {
-		log.Printf("Invoking: %s [%s]", c.String(), c.Schedule)
-		if _, err := c.InvokeFunction(invoker); err != nil {
-			log.Printf("Error: %s", err)
+	schedule, err := s.parse(c)
+	if err != nil {
+		return ScheduledFunction{Function: c}, err
+	}
+
+	job := &cronJob{
+		function:  c,
+		invoker:   invoker,
+		scheduler: s,
+	}
+
+	planned := newPlannedSchedule(schedule)
+	job.schedule.Store(planned)
+	eID := s.main.Schedule(planned, job)
+
+	s.catchUp(job, schedule)
+
+	return ScheduledFunction{
+		Function:   c,
+		ID:         EntryID(eID),
+		Discovered: time.Now(),
+	}, nil
+}
+
+// Update replaces the settings of a scheduled function with
+// function.Function, which must be the same schedule of the same function.
+// The function keeps its run state, and keeps its cron entry unless its
+// timezone or schedule format changed.
+func (s *Scheduler) Update(function ScheduledFunction) (ScheduledFunction, error) {
+	c := function.Function
+
+	job, ok := s.main.Entry(cron.EntryID(function.ID)).Job.(*cronJob)
+	if !ok {
+		return function, fmt.Errorf("%s is not scheduled", c.String())
+	}
+
+	previous := job.spec()
+	if previous.Timezone != c.Timezone || previous.ScheduleFormat != c.ScheduleFormat {
+		schedule, err := s.parse(c)
+		if err != nil {
+			return function, err
 		}
-	})
-	return ScheduledFunction{c, EntryID(eID)}, err
+
+		s.main.Remove(cron.EntryID(function.ID))
+		job.setSpec(c)
+
+		planned := newPlannedSchedule(schedule)
+		job.schedule.Store(planned)
+		function.ID = EntryID(s.main.Schedule(planned, job))
+
+		return function, nil
+	}
+
+	job.setSpec(c)
+	return function, nil
 }
 
 // Remove removes the function from scheduler
 func (s *Scheduler) Remove(function ScheduledFunction) {
 	s.main.Remove(cron.EntryID(function.ID))
+
+	if s.state != nil {
+		s.state.Remove(function.Function.stateKey())
+	}
+}
+
+// ScheduleStatus describes a ScheduledFunction and when it runs
+type ScheduleStatus struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Schedule  string `json:"schedule"`
+
+	// Resolved is the schedule with its H tokens replaced
+	Resolved string `json:"resolved,omitempty"`
+
+	Timezone   string    `json:"timezone,omitempty"`
+	EntryID    EntryID   `json:"entry_id"`
+	Discovered time.Time `json:"discovered"`
+
+	// Suspended is set by the suspend annotation, and Paused
+	// through the admin API
+	Suspended bool `json:"suspended"`
+	Paused    bool `json:"paused"`
+
+	// Next and Prev are the next and previous times the function
+	// runs, Prev is nil until the function has run
+	Next *time.Time `json:"next,omitempty"`
+	Prev *time.Time `json:"prev,omitempty"`
+}
+
+// Status returns the status of function, as known to the scheduler
+func (s *Scheduler) Status(function ScheduledFunction) ScheduleStatus {
+	c := function.Function
+
+	status := ScheduleStatus{
+		Name:       c.Name,
+		Namespace:  c.Namespace,
+		Schedule:   c.Schedule,
+		Timezone:   c.Timezone,
+		EntryID:    function.ID,
+		Discovered: function.Discovered,
+		Suspended:  c.Suspended,
+		Paused:     s.Paused(c),
+	}
+
+	if resolved, err := c.ResolvedSchedule(); err == nil && resolved != c.Schedule {
+		status.Resolved = resolved
+	}
+
+	entry := s.main.Entry(cron.EntryID(function.ID))
+	if !entry.Next.IsZero() {
+		status.Next = &entry.Next
+	}
+	if !entry.Prev.IsZero() {
+		status.Prev = &entry.Prev
+	}
+
+	return status
 }
 
 // CheckSchedule returns true if the schedule string is compliant with cron
+// in the standard format
 func CheckSchedule(schedule string) bool {
-	_, err := standardParser.Parse(schedule)
+	return CheckScheduleFormat(schedule, StandardFormat)
+}
+
+// CheckScheduleFormat returns true if the schedule string is compliant with
+// cron in the given format, H tokens are accepted
+func CheckScheduleFormat(schedule string, format ScheduleFormat) bool {
+	resolved, err := ResolveHashedSchedule(schedule, format, "")
+	if err != nil {
+		return false
+	}
+
+	_, err = format.parser().Parse(resolved)
 	return err == nil
 }
 
-// Contains returns true if the ScheduledFunctions array contains the CronFunction
+// Contains returns true if the ScheduledFunctions array contains the
+// CronFunction, with the same spec
 func (functions *ScheduledFunctions) Contains(cronFunc *CronFunction) bool {
 	for _, f := range *functions {
-		if f.Function.Name == cronFunc.Name &&
-			f.Function.Namespace == cronFunc.Namespace &&
-			f.Function.Schedule == cronFunc.Schedule {
+		if f.Function.SameSchedule(cronFunc) && f.Function.SpecHash == cronFunc.SpecHash {
 			return true
 		}
 	}
 
 	return false
 }
+
+// Find returns the ScheduledFunction for the same schedule of the same
+// function as cronFunc
+func (functions *ScheduledFunctions) Find(cronFunc *CronFunction) (ScheduledFunction, bool) {
+	for _, f := range *functions {
+		if f.Function.SameSchedule(cronFunc) {
+			return f, true
+		}
+	}
+
+	return ScheduledFunction{}, false
+}
@@ -0,0 +1,287 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+	cron "github.com/robfig/cron/v3"
+)
+
+func TestCheckSchedule(t *testing.T) {
+	testcases := []struct {
+		Schedule string
+		Want     bool
+	}{
+		{Schedule: "*/5 * * * *", Want: true},
+		{Schedule: "@daily", Want: true},
+		{Schedule: "*/10 * * * * *", Want: false},
+		{Schedule: "not a schedule", Want: false},
+	}
+
+	for _, tc := range testcases {
+		if got := CheckSchedule(tc.Schedule); got != tc.Want {
+			t.Errorf("%q, expected: %v, got: %v", tc.Schedule, tc.Want, got)
+		}
+	}
+}
+
+func TestCheckScheduleFormat(t *testing.T) {
+	testcases := []struct {
+		Schedule string
+		Format   ScheduleFormat
+		Want     bool
+	}{
+		{Schedule: "*/5 * * * *", Format: StandardFormat, Want: true},
+		{Schedule: "*/5 * * * *", Format: SecondsFormat, Want: true},
+		{Schedule: "*/10 * * * * *", Format: StandardFormat, Want: false},
+		{Schedule: "*/10 * * * * *", Format: SecondsFormat, Want: true},
+		{Schedule: "@hourly", Format: SecondsFormat, Want: true},
+		{Schedule: "60 * * * * *", Format: SecondsFormat, Want: false},
+		{Schedule: "CRON_TZ=Asia/Ho_Chi_Minh @daily", Format: StandardFormat, Want: true},
+		{Schedule: "TZ=Europe/Helsinki @hourly", Format: StandardFormat, Want: true},
+	}
+
+	for _, tc := range testcases {
+		if got := CheckScheduleFormat(tc.Schedule, tc.Format); got != tc.Want {
+			t.Errorf("%q in %s format, expected: %v, got: %v", tc.Schedule, tc.Format, tc.Want, got)
+		}
+	}
+}
+
+func TestSecondsFormat_KeepsFiveFieldMeaning(t *testing.T) {
+	from := time.Date(2024, 1, 1, 10, 2, 0, 0, time.UTC)
+
+	standard, err := StandardFormat.parser().Parse("*/5 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	seconds, err := SecondsFormat.parser().Parse("*/5 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := standard.Next(from)
+	if got := seconds.Next(from); !got.Equal(want) {
+		t.Errorf("expected: %s, got: %s", want, got)
+	}
+}
+
+func TestToCronFunctions_ScheduleFormat(t *testing.T) {
+	testcases := []struct {
+		Name       string
+		Schedule   string
+		Annotation string
+		Default    ScheduleFormat
+		Want       ScheduleFormat
+		WantErr    bool
+	}{
+		{Name: "standard by default", Schedule: "* * * * *", Want: StandardFormat},
+		{Name: "seconds rejected by default", Schedule: "*/10 * * * * *", WantErr: true},
+		{Name: "seconds from annotation", Schedule: "*/10 * * * * *", Annotation: "seconds", Want: SecondsFormat},
+		{Name: "seconds from connector default", Schedule: "*/10 * * * * *", Default: SecondsFormat, Want: SecondsFormat},
+		{Name: "annotation overrides default", Schedule: "*/10 * * * * *", Annotation: "standard", Default: SecondsFormat, WantErr: true},
+		{Name: "unknown format", Schedule: "* * * * *", Annotation: "quartz", WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			annotations := map[string]string{"schedule": tc.Schedule}
+			if len(tc.Annotation) > 0 {
+				annotations["schedule_format"] = tc.Annotation
+			}
+
+			cfs := testCronFunctions(t, tc.WantErr, Defaults{ScheduleFormat: tc.Default}, annotations)
+			if tc.WantErr {
+				return
+			}
+			cf := cfs[0]
+
+			if cf.ScheduleFormat != tc.Want {
+				t.Errorf("expected: %s, got: %s", tc.Want, cf.ScheduleFormat)
+			}
+		})
+	}
+}
+
+func TestScheduler_Status(t *testing.T) {
+	annotations := map[string]string{
+		"topic":             "cron-function",
+		"schedule":          "H 2 * * *",
+		"schedule_timezone": "Europe/London",
+	}
+	f := ptypes.FunctionStatus{Name: "nightly", Annotations: &annotations}
+
+	cfs, err := ToCronFunctions(f, "openfaas-fn", "cron-function", Defaults{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	s := NewScheduler()
+	s.Start()
+	defer s.main.Stop()
+
+	scheduled, err := s.AddCronFunction(cfs[0], nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	status := s.Status(scheduled)
+
+	if status.EntryID != scheduled.ID || status.Name != "nightly" || status.Timezone != "Europe/London" {
+		t.Errorf("expected the function's details, got: %+v", status)
+	}
+	if len(status.Resolved) == 0 || status.Resolved == status.Schedule {
+		t.Errorf("expected the resolved schedule, got: %q", status.Resolved)
+	}
+	if status.Discovered.IsZero() {
+		t.Error("expected the discovery time to be set")
+	}
+	if status.Next == nil || status.Next.In(time.UTC).Before(time.Now()) {
+		t.Errorf("expected the next run to be in the future, got: %v", status.Next)
+	}
+	if status.Prev != nil {
+		t.Errorf("expected no previous run, got: %s", status.Prev)
+	}
+}
+
+func TestScheduler_Heartbeat(t *testing.T) {
+	s := NewScheduler()
+	if !s.LastHeartbeat().IsZero() {
+		t.Error("expected no heartbeat before the scheduler is started")
+	}
+
+	s.Start()
+	defer s.main.Stop()
+
+	if time.Since(s.LastHeartbeat()) > time.Second {
+		t.Errorf("expected a heartbeat when the scheduler starts, got: %s", s.LastHeartbeat())
+	}
+}
+
+func TestScheduler_Update(t *testing.T) {
+	toCronFunction := func(annotations map[string]string) CronFunction {
+		annotations["topic"] = "cron-function"
+		annotations["schedule"] = "0 2 * * *"
+
+		cfs, err := ToCronFunctions(ptypes.FunctionStatus{Name: "nightly", Annotations: &annotations}, "openfaas-fn", "cron-function", Defaults{})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return cfs[0]
+	}
+
+	s := NewScheduler()
+	s.Start()
+	defer s.main.Stop()
+
+	scheduled, err := s.AddCronFunction(toCronFunction(map[string]string{"payload": "v1"}), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	t.Run("in place", func(t *testing.T) {
+		scheduled.Function = toCronFunction(map[string]string{"payload": "v2"})
+		updated, err := s.Update(scheduled)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if updated.ID != scheduled.ID || !updated.Discovered.Equal(scheduled.Discovered) {
+			t.Errorf("expected the function to keep its entry, got: %+v", updated)
+		}
+
+		job := s.main.Entry(cron.EntryID(updated.ID)).Job.(*cronJob)
+		if got := string(job.spec().Payload); got != "v2" {
+			t.Errorf("expected the job to use the new payload, got: %q", got)
+		}
+		scheduled = updated
+	})
+
+	t.Run("timezone", func(t *testing.T) {
+		scheduled.Function = toCronFunction(map[string]string{"payload": "v2", "schedule_timezone": "Asia/Tokyo"})
+		updated, err := s.Update(scheduled)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if s.main.Entry(cron.EntryID(scheduled.ID)).Valid() {
+			t.Error("expected the previous entry to be removed")
+		}
+
+		status := s.Status(updated)
+		if status.Timezone != "Asia/Tokyo" || status.Next == nil || status.Next.In(time.UTC).Hour() != 17 {
+			t.Errorf("expected the function to run at 2am in Tokyo, got: %+v", status)
+		}
+	})
+
+	t.Run("not scheduled", func(t *testing.T) {
+		if _, err := s.Update(ScheduledFunction{Function: scheduled.Function, ID: 1000}); err == nil {
+			t.Error("expected an error for a function which is not scheduled")
+		}
+	})
+}
+
+func TestPlannedSchedule(t *testing.T) {
+	schedule := newPlannedSchedule(cron.Every(time.Minute))
+	start := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+
+	first := schedule.Next(start)
+	if got := schedule.planned(start); !got.IsZero() {
+		t.Errorf("expected no planned time before the first run, got: %s", got)
+	}
+
+	// The job may start before or after cron asks for the next time
+	if got := schedule.planned(first.Add(time.Millisecond)); !got.Equal(first) {
+		t.Errorf("expected: %s, got: %s", first, got)
+	}
+
+	second := schedule.Next(first)
+	if got := schedule.planned(first.Add(time.Millisecond)); !got.Equal(first) {
+		t.Errorf("expected: %s, got: %s", first, got)
+	}
+	if got := schedule.planned(second.Add(time.Second)); !got.Equal(second) {
+		t.Errorf("expected: %s, got: %s", second, got)
+	}
+}
+
+func TestScheduler_ScheduledTime(t *testing.T) {
+	scheduled := make(chan string, 10)
+	invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+		scheduled <- r.Header.Get(ScheduledTimeHeader)
+	}, 10)
+
+	annotations := map[string]string{"topic": "cron-function"}
+	s := NewScheduler()
+	s.Start()
+	defer s.main.Stop()
+
+	_, err := s.AddCronFunction(CronFunction{
+		FuncData:       ptypes.FunctionStatus{Annotations: &annotations},
+		Name:           "ticker",
+		Namespace:      "openfaas-fn",
+		Schedule:       "* * * * * *",
+		ScheduleFormat: SecondsFormat,
+	}, invoker)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		select {
+		case value := <-scheduled:
+			when, err := time.Parse(time.RFC3339, value)
+			if err != nil || seen[value] || when.After(time.Now()) {
+				t.Fatalf("expected a distinct planned time in the past, got: %q %v", value, err)
+			}
+			seen[value] = true
+		case <-time.After(3 * time.Second):
+			t.Fatal("timed out waiting for the function to run")
+		}
+	}
+}
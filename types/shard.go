@@ -0,0 +1,230 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ringReplicas is how many points each member has on the hash ring, more
+// points spread functions more evenly between members
+const ringReplicas = 64
+
+// Membership discovers the replicas of the connector which are running,
+// so that functions can be split between them
+type Membership interface {
+	// Members returns the identity of each replica which is running
+	Members(ctx context.Context) ([]string, error)
+}
+
+// StaticMembership is a fixed list of members
+type StaticMembership []string
+
+// Members returns the list of members
+func (m StaticMembership) Members(ctx context.Context) ([]string, error) {
+	return m, nil
+}
+
+// hashRing assigns each key to one member by consistent hashing, so that
+// when a member joins or leaves only the keys it gains or loses move
+type hashRing struct {
+	points []uint64
+	owners map[uint64]string
+}
+
+func newHashRing(members []string) *hashRing {
+	r := &hashRing{
+		owners: make(map[uint64]string, len(members)*ringReplicas),
+	}
+
+	for _, member := range members {
+		for i := 0; i < ringReplicas; i++ {
+			point := ringHash(member + "#" + strconv.Itoa(i))
+			// Collisions are settled by name, so every replica
+			// builds the same ring
+			if owner, ok := r.owners[point]; ok && owner < member {
+				continue
+			}
+			if _, ok := r.owners[point]; !ok {
+				r.points = append(r.points, point)
+			}
+			r.owners[point] = member
+		}
+	}
+
+	slices.Sort(r.points)
+	return r
+}
+
+// owner returns the member which key is assigned to, the first point on
+// the ring at or after the key's hash
+func (r *hashRing) owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	hash := ringHash(key)
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= hash
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+
+	return r.owners[r.points[i]]
+}
+
+func ringHash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	// Mix the bits, as FNV gives similar hashes for keys which only
+	// differ in their last characters
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// Sharder splits functions between the replicas of the connector, each
+// function is scheduled by the one replica which owns its namespace and
+// name on a hash ring of the members
+type Sharder struct {
+	identity   string
+	membership Membership
+
+	mu      sync.RWMutex
+	members []string
+	ring    *hashRing
+}
+
+// NewSharder returns a Sharder for identity, which must be unique to each
+// replica and match the identities returned by membership
+func NewSharder(identity string, membership Membership) *Sharder {
+	return &Sharder{
+		identity:   identity,
+		membership: membership,
+	}
+}
+
+// Refresh reads the members and rebuilds the ring when they have changed.
+// This replica is always a member, as it is running. On an error the
+// previous members are kept.
+func (s *Sharder) Refresh(ctx context.Context) error {
+	found, err := s.membership.Members(ctx)
+	if err != nil {
+		return err
+	}
+
+	members := []string{s.identity}
+	for _, member := range found {
+		member = strings.TrimSpace(member)
+		if len(member) > 0 && !slices.Contains(members, member) {
+			members = append(members, member)
+		}
+	}
+	slices.Sort(members)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ring != nil && slices.Equal(s.members, members) {
+		return nil
+	}
+
+	s.members = members
+	s.ring = newHashRing(members)
+
+	log.Printf("Shard members: %s (%d), this replica: %s", strings.Join(members, ", "), len(members), s.identity)
+	return nil
+}
+
+// Run refreshes the members every interval until ctx is done. When the
+// membership is an io.Closer it is closed on exit, so that this replica
+// leaves straight away.
+func (s *Sharder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if closer, ok := s.membership.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					log.Printf("Error leaving shard members: %s", err)
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.Refresh(ctx); err != nil {
+			log.Printf("Error listing shard members: %s", err)
+		}
+	}
+}
+
+// Ready returns true once the members have been read
+func (s *Sharder) Ready() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.ring != nil
+}
+
+// Members returns the members the ring was built from
+func (s *Sharder) Members() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.members)
+}
+
+// Identity returns the identity of this replica
+func (s *Sharder) Identity() string {
+	return s.identity
+}
+
+// Owner returns the identity of the replica which schedules the function
+// with namespace and name, or an empty string until the members are read
+func (s *Sharder) Owner(namespace, name string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.ring == nil {
+		return ""
+	}
+
+	return s.ring.owner(namespace + "/" + name)
+}
+
+// Owns returns true if c is scheduled by this replica. Each schedule of
+// a function is owned by the same replica.
+func (s *Sharder) Owns(c CronFunction) bool {
+	return s.Owner(c.Namespace, c.Name) == s.identity
+}
+
+// Filter returns the functions owned by this replica
+func (s *Sharder) Filter(functions CronFunctions) CronFunctions {
+	owned := make(CronFunctions, 0, len(functions))
+	for _, function := range functions {
+		if s.Owns(function) {
+			owned = append(owned, function)
+		}
+	}
+
+	return owned
+}
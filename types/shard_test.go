@@ -0,0 +1,186 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func testShardFunctions(n int) CronFunctions {
+	functions := make(CronFunctions, 0, n*2)
+	for i := 0; i < n; i++ {
+		for _, schedule := range []string{"*/5 * * * *", "0 2 * * *"} {
+			functions = append(functions, CronFunction{
+				Name:      fmt.Sprintf("fn-%d", i),
+				Namespace: []string{"openfaas-fn", "staging-fn"}[i%2],
+				Schedule:  schedule,
+			})
+		}
+	}
+	return functions
+}
+
+// shardOwners returns the replica which schedules each function, and
+// fails if any function is scheduled on no replica or on more than one
+func shardOwners(t *testing.T, functions CronFunctions, members []string) map[string]string {
+	t.Helper()
+
+	sharders := make(map[string]*Sharder, len(members))
+	for _, member := range members {
+		sharders[member] = NewSharder(member, StaticMembership(members))
+		if err := sharders[member].Refresh(context.Background()); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	owners := make(map[string]string, len(functions))
+	for _, function := range functions {
+		key := function.Namespace + "/" + function.Name + " " + function.Schedule
+
+		shards := []string{}
+		for member, sharder := range sharders {
+			if len(sharder.Filter(CronFunctions{function})) == 1 {
+				shards = append(shards, member)
+			}
+		}
+
+		if len(shards) != 1 {
+			t.Fatalf("expected %s to be scheduled on one shard, got: %v", key, shards)
+		}
+		owners[key] = shards[0]
+	}
+
+	return owners
+}
+
+func TestSharder_EachFunctionOnOneShard(t *testing.T) {
+	testcases := []struct {
+		Name    string
+		Members []string
+	}{
+		{Name: "one replica", Members: []string{"a"}},
+		{Name: "three replicas", Members: []string{"a", "b", "c"}},
+		{Name: "ten replicas", Members: []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"}},
+	}
+
+	functions := testShardFunctions(500)
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			owners := shardOwners(t, functions, tc.Members)
+
+			counts := map[string]int{}
+			for _, owner := range owners {
+				counts[owner]++
+			}
+			for _, member := range tc.Members {
+				if counts[member] == 0 {
+					t.Errorf("expected %s to own some functions, got: %v", member, counts)
+				}
+			}
+
+			byFunction := map[string]string{}
+			for _, function := range functions {
+				name := function.Namespace + "/" + function.Name
+				owner := owners[name+" "+function.Schedule]
+				if first, ok := byFunction[name]; ok && first != owner {
+					t.Fatalf("expected each schedule of %s to be on the same shard, got: %s and %s", name, first, owner)
+				}
+				byFunction[name] = owner
+			}
+		})
+	}
+}
+
+func TestSharder_Rebalances(t *testing.T) {
+	functions := testShardFunctions(500)
+	before := shardOwners(t, functions, []string{"a", "b", "c"})
+
+	t.Run("replica joins", func(t *testing.T) {
+		after := shardOwners(t, functions, []string{"a", "b", "c", "d"})
+
+		moved := 0
+		for key, owner := range after {
+			if owner != before[key] {
+				if owner != "d" {
+					t.Fatalf("expected %s to stay on %s or move to d, got: %s", key, before[key], owner)
+				}
+				moved++
+			}
+		}
+		if moved == 0 {
+			t.Error("expected some functions to move to the new replica")
+		}
+	})
+
+	t.Run("replica leaves", func(t *testing.T) {
+		after := shardOwners(t, functions, []string{"a", "c"})
+
+		for key, owner := range after {
+			if before[key] != "b" && owner != before[key] {
+				t.Fatalf("expected %s to stay on %s, got: %s", key, before[key], owner)
+			}
+		}
+	})
+}
+
+func TestSharder_NotReady(t *testing.T) {
+	s := NewSharder("a", StaticMembership{"a"})
+
+	if s.Ready() || len(s.Filter(testShardFunctions(10))) != 0 {
+		t.Fatal("expected no functions to be owned before the members are read")
+	}
+
+	s.Refresh(context.Background())
+	if !s.Ready() || len(s.Filter(testShardFunctions(10))) != 20 {
+		t.Fatal("expected a single replica to own every function")
+	}
+}
+
+func TestSharder_IncludesItself(t *testing.T) {
+	s := NewSharder("c", StaticMembership{"a", " b", ""})
+	s.Refresh(context.Background())
+
+	if got, want := s.Members(), []string{"a", "b", "c"}; !slices.Equal(got, want) {
+		t.Errorf("expected: %v, got: %v", want, got)
+	}
+}
+
+func TestFileMembership(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "shards")
+	ctx := context.Background()
+
+	a := NewFileMembership(dir, "10.0.0.1:8080", time.Minute)
+	b := NewFileMembership(dir, "b", time.Minute)
+
+	a.Members(ctx)
+	members, err := b.Members(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	slices.Sort(members)
+	if want := []string{"10.0.0.1:8080", "b"}; !slices.Equal(members, want) {
+		t.Fatalf("expected: %v, got: %v", want, members)
+	}
+
+	if err := a.Close(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	members, _ = b.Members(ctx)
+	if want := []string{"b"}; !slices.Equal(members, want) {
+		t.Fatalf("expected a replica which left to be removed, got: %v", members)
+	}
+
+	expiring := NewFileMembership(dir, "c", time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	members, _ = expiring.Members(ctx)
+	if want := []string{"c"}; !slices.Equal(members, want) {
+		t.Fatalf("expected replicas which were not renewed to expire, got: %v", members)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrShuttingDown is reported for runs which were cancelled, or not
+// started, because the connector is shutting down
+var ErrShuttingDown = errors.New("connector shutting down")
+
+// abandonTimeout is how long runs which were cancelled on shutdown are
+// given to report their outcome
+const abandonTimeout = time.Second * 5
+
+// Stop stops the scheduler from starting new runs and waits for the runs
+// in flight to finish. Runs still in flight when ctx is done are cancelled
+// and returned, once they have reported their outcome or abandonTimeout
+// has passed.
+func (s *Scheduler) Stop(ctx context.Context) []Run {
+	s.mu.Lock()
+	s.stopping = true
+	s.mu.Unlock()
+
+	s.main.Stop()
+
+	if s.waitForRuns(ctx) {
+		return nil
+	}
+
+	s.mu.Lock()
+	abandoned := make([]Run, 0, len(s.active))
+	for _, active := range s.active {
+		abandoned = append(abandoned, active.run)
+		active.cancel(ErrShuttingDown)
+	}
+	s.mu.Unlock()
+
+	abandonCtx, cancel := context.WithTimeout(context.Background(), abandonTimeout)
+	defer cancel()
+	s.waitForRuns(abandonCtx)
+
+	return abandoned
+}
+
+// waitForRuns returns true once no runs are in flight, or false if ctx
+// is done first
+func (s *Scheduler) waitForRuns(ctx context.Context) bool {
+	ticker := time.NewTicker(time.Millisecond * 50)
+	defer ticker.Stop()
+
+	for {
+		s.mu.Lock()
+		n := len(s.active)
+		s.mu.Unlock()
+
+		if n == 0 {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stopping returns true once Stop has been called
+func (s *Scheduler) Stopping() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.stopping
+}
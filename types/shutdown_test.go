@@ -0,0 +1,68 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduler_StopWaitsForRuns(t *testing.T) {
+	s := NewScheduler()
+	s.Start()
+
+	c := CronFunction{Name: "job", Namespace: "openfaas-fn"}
+	_, done, ok := s.startRun(c, NewRun(time.Now()))
+	if !ok {
+		t.Fatal("expected the run to start")
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		done()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if abandoned := s.Stop(ctx); len(abandoned) != 0 {
+		t.Errorf("expected no abandoned runs, got: %v", abandoned)
+	}
+
+	if _, _, ok := s.startRun(c, NewRun(time.Now())); ok {
+		t.Error("expected no runs to start once stopped")
+	}
+}
+
+func TestScheduler_StopAbandonsRuns(t *testing.T) {
+	s := NewScheduler()
+	s.Start()
+
+	c := CronFunction{Name: "job", Namespace: "openfaas-fn"}
+	run := NewRun(time.Now())
+	runCtx, done, ok := s.startRun(c, run)
+	if !ok {
+		t.Fatal("expected the run to start")
+	}
+
+	// The run exits as soon as it is cancelled
+	go func() {
+		<-runCtx.Done()
+		done()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	abandoned := s.Stop(ctx)
+	if len(abandoned) != 1 || abandoned[0].ID != run.ID {
+		t.Fatalf("expected run %s to be abandoned, got: %v", run.ID, abandoned)
+	}
+
+	if !errors.Is(context.Cause(runCtx), ErrShuttingDown) {
+		t.Errorf("expected the run to be cancelled with: %s, got: %v", ErrShuttingDown, context.Cause(runCtx))
+	}
+}
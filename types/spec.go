@@ -0,0 +1,63 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+// specAnnotations are the annotations which decide how a function is
+// scheduled and invoked. The schedule is left out, as each expression is
+// scheduled on its own and adding or removing one must not update the rest.
+var specAnnotations = []string{
+	"topic",
+	"schedule_timezone",
+	"schedule_format",
+	"payload",
+	"payload_base64",
+	"payload_template",
+	"content_type",
+	"headers",
+	"secret_headers",
+	"method",
+	"path",
+	"query",
+	"concurrency_policy",
+	"retry_max_attempts",
+	"retry_backoff",
+	"retry_max_backoff",
+	"retry_status_codes",
+	"timeout",
+	"jitter",
+	"jitter_mode",
+	"starting_deadline",
+	"catchup_policy",
+	"suspend",
+}
+
+// SpecHash returns a hash of the function's image and its spec
+// annotations, which changes whenever any of them do
+func SpecHash(f ptypes.FunctionStatus) string {
+	h := sha256.New()
+	h.Write([]byte(f.Image))
+
+	for _, name := range specAnnotations {
+		h.Write([]byte{0})
+		h.Write([]byte(name))
+
+		// A missing annotation differs from an empty one
+		if f.Annotations == nil {
+			continue
+		}
+		if value, ok := (*f.Annotations)[name]; ok {
+			h.Write([]byte{1})
+			h.Write([]byte(value))
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,126 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// readSuspend parses the suspend annotation
+func readSuspend(annotations map[string]string) (bool, error) {
+	v, ok := annotations["suspend"]
+	if !ok {
+		return false, nil
+	}
+
+	suspend, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("suspend must be true or false: %q", v)
+	}
+
+	return suspend, nil
+}
+
+// pauseKey identifies a function in the PauseState, pausing a function
+// pauses each of its schedule expressions
+func (c *CronFunction) pauseKey() string {
+	return pauseKey(c.Namespace, c.Name)
+}
+
+func pauseKey(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
+
+// PauseState records which functions have been paused at runtime in a
+// file on local disk, so that they stay paused when the connector
+// restarts
+type PauseState struct {
+	path string
+
+	mu     sync.Mutex
+	paused []string
+}
+
+// NewPauseState loads the pause state from path, a missing file gives
+// an empty state
+func NewPauseState(path string) (*PauseState, error) {
+	state := &PauseState{
+		path:   path,
+		paused: []string{},
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &state.paused); err != nil {
+		return nil, fmt.Errorf("unable to parse pause state %s: %w", path, err)
+	}
+
+	return state, nil
+}
+
+// Paused returns true if the function with key is paused
+func (p *PauseState) Paused(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Contains(p.paused, key)
+}
+
+// Set pauses or resumes the function with key
+func (p *PauseState) Set(key string, paused bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	i := slices.Index(p.paused, key)
+	switch {
+	case paused && i < 0:
+		p.paused = append(p.paused, key)
+	case !paused && i >= 0:
+		p.paused = slices.Delete(p.paused, i, i+1)
+	default:
+		return nil
+	}
+
+	data, err := json.Marshal(p.paused)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(p.path, data)
+}
+
+// Pause stops the function in namespace with name from running on its
+// schedule until it is resumed
+func (s *Scheduler) Pause(namespace, name string) error {
+	return s.setPaused(namespace, name, true)
+}
+
+// Resume lets a paused function run on its schedule again
+func (s *Scheduler) Resume(namespace, name string) error {
+	return s.setPaused(namespace, name, false)
+}
+
+func (s *Scheduler) setPaused(namespace, name string, paused bool) error {
+	if s.pauses == nil {
+		return fmt.Errorf("pausing functions is not enabled")
+	}
+
+	return s.pauses.Set(pauseKey(namespace, name), paused)
+}
+
+// Paused returns true if c has been paused at runtime
+func (s *Scheduler) Paused(c CronFunction) bool {
+	return s.pauses != nil && s.pauses.Paused(c.pauseKey())
+}
@@ -0,0 +1,135 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"net/http"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	ptypes "github.com/openfaas/faas-provider/types"
+)
+
+func TestToCronFunctions_Suspend(t *testing.T) {
+	testcases := []struct {
+		Name    string
+		Suspend string
+		Want    bool
+		WantErr bool
+	}{
+		{Name: "not set", Want: false},
+		{Name: "true", Suspend: "true", Want: true},
+		{Name: "false", Suspend: "false", Want: false},
+		{Name: "invalid", Suspend: "sometimes", WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			annotations := map[string]string{}
+			if len(tc.Suspend) > 0 {
+				annotations["suspend"] = tc.Suspend
+			}
+
+			cfs := testCronFunctions(t, tc.WantErr, Defaults{}, annotations)
+			if tc.WantErr {
+				return
+			}
+
+			if cfs[0].Suspended != tc.Want {
+				t.Errorf("expected: %v, got: %v", tc.Want, cfs[0].Suspended)
+			}
+		})
+	}
+}
+
+func TestPauseState_Persists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "paused.json")
+
+	state, err := NewPauseState(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	s := NewScheduler(WithPauseState(state))
+	if err := s.Pause("openfaas-fn", "nightly"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := s.Pause("openfaas-fn", "hourly"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := s.Resume("openfaas-fn", "hourly"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	reloaded, err := NewPauseState(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	s = NewScheduler(WithPauseState(reloaded))
+	if !s.Paused(CronFunction{Name: "nightly", Namespace: "openfaas-fn", Schedule: "0 2 * * *"}) {
+		t.Error("expected nightly to stay paused")
+	}
+	if s.Paused(CronFunction{Name: "hourly", Namespace: "openfaas-fn"}) {
+		t.Error("expected hourly to be resumed")
+	}
+	if s.Paused(CronFunction{Name: "nightly", Namespace: "staging"}) {
+		t.Error("expected nightly in another namespace not to be paused")
+	}
+}
+
+func TestCronJob_SkipsSuspendedRuns(t *testing.T) {
+	testcases := []struct {
+		Name      string
+		Suspended bool
+		Paused    bool
+		Want      int32
+	}{
+		{Name: "running", Want: 1},
+		{Name: "suspended by annotation", Suspended: true, Want: 0},
+		{Name: "paused at runtime", Paused: true, Want: 0},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var calls atomic.Int32
+			invoker := testInvoker(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+			}, 1)
+
+			dir := t.TempDir()
+			runState, _ := NewRunState(filepath.Join(dir, "last_runs.json"))
+			pauseState, _ := NewPauseState(filepath.Join(dir, "paused.json"))
+			s := NewScheduler(WithRunState(runState), WithPauseState(pauseState))
+
+			annotations := map[string]string{"topic": "cron-function"}
+			c := CronFunction{
+				FuncData:  ptypes.FunctionStatus{Annotations: &annotations},
+				Name:      "nightly",
+				Namespace: "openfaas-fn",
+				Schedule:  "0 2 * * *",
+				Suspended: tc.Suspended,
+
+				StartingDeadline: time.Hour,
+			}
+			if tc.Paused {
+				s.Pause(c.Namespace, c.Name)
+			}
+
+			scheduled := time.Date(2024, 6, 1, 2, 0, 0, 0, time.UTC)
+			job := &cronJob{function: c, invoker: invoker, scheduler: s}
+			job.run(scheduled)
+
+			if got := calls.Load(); got != tc.Want {
+				t.Errorf("expected %d invocations, got: %d", tc.Want, got)
+			}
+
+			if last, ok := runState.LastRun(c.stateKey()); !ok || !last.Equal(scheduled) {
+				t.Errorf("expected the run to be recorded, got: %s", last)
+			}
+		})
+	}
+}
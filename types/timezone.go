@@ -0,0 +1,123 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	cron "github.com/robfig/cron/v3"
+)
+
+// zonedSchedule evaluates a cron expression against the wall clock of a
+// given location.
+//
+// Daylight saving time transitions are handled as follows:
+//
+//   - Spring forward: wall-clock times which do not exist, because the clocks
+//     skip over them, are not lost. All runs which fall into the gap are
+//     coalesced into a single run at the first instant after the transition.
+//   - Fall back: wall-clock times which occur twice only run once, on their
+//     first occurrence.
+type zonedSchedule struct {
+	// schedule is evaluated in UTC, which has no transitions, and
+	// is given the wall-clock time of the location to work from
+	schedule cron.Schedule
+
+	location *time.Location
+}
+
+// newZonedSchedule wraps a parsed schedule so that it is evaluated in location.
+// Schedules which are not tied to the wall clock, such as "@every 1h", are
+// returned as they are.
+func newZonedSchedule(schedule cron.Schedule, location *time.Location) cron.Schedule {
+	spec, ok := schedule.(*cron.SpecSchedule)
+	if !ok {
+		return schedule
+	}
+
+	utc := *spec
+	utc.Location = time.UTC
+
+	return &zonedSchedule{
+		schedule: &utc,
+		location: location,
+	}
+}
+
+// Next returns the next time this schedule is activated, greater than t.
+func (z *zonedSchedule) Next(t time.Time) time.Time {
+	wall := toWallClock(t.In(z.location))
+
+	for {
+		next := z.schedule.Next(wall)
+		if next.IsZero() {
+			return next
+		}
+
+		at := fromWallClock(next, z.location)
+		if at.After(t) {
+			return at.In(t.Location())
+		}
+
+		wall = next
+	}
+}
+
+// LoadTimezone returns the location for an IANA time zone name such as
+// "Europe/London".
+func LoadTimezone(name string) (*time.Location, error) {
+	// time.LoadLocation treats "" as UTC and "Local" as the process
+	// time zone, neither of which is an explicit choice by the user
+	if len(name) == 0 || name == "Local" {
+		return nil, fmt.Errorf("invalid timezone: %q", name)
+	}
+
+	return time.LoadLocation(name)
+}
+
+// hasTimezonePrefix returns true if the schedule sets its own time zone
+// with the TZ= or CRON_TZ= prefix
+func hasTimezonePrefix(schedule string) bool {
+	return strings.HasPrefix(schedule, "TZ=") || strings.HasPrefix(schedule, "CRON_TZ=")
+}
+
+// toWallClock returns the wall-clock time of t expressed in UTC
+func toWallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// fromWallClock returns the earliest instant at which the wall clock in
+// location reads wall, or the end of the transition if wall was skipped
+func fromWallClock(wall time.Time, location *time.Location) time.Time {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), location)
+
+	start, end := t.ZoneBounds()
+
+	if !toWallClock(t).Equal(wall) {
+		// wall falls into a gap, so run as soon as the clocks have moved
+		if toWallClock(t).After(wall) {
+			return start
+		}
+		return end
+	}
+
+	if start.IsZero() {
+		return t
+	}
+
+	// When the clocks go back, the same wall-clock time occurs in the
+	// zone before the transition as well
+	_, offset := t.Zone()
+	_, offsetBefore := start.Add(-time.Nanosecond).Zone()
+	if offsetBefore > offset {
+		earlier := t.Add(-time.Duration(offsetBefore-offset) * time.Second)
+		if earlier.Before(start) && toWallClock(earlier.In(location)).Equal(wall) {
+			return earlier
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,196 @@
+// Copyright (c) OpenFaaS Author(s) 2026. All rights reserved.
+// Licensed under the MIT license. See LICENSE file in the project root for full license information.
+
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func nextRuns(t *testing.T, schedule, timezone string, from time.Time, n int) []time.Time {
+	t.Helper()
+
+	spec, err := standardParser.Parse(schedule)
+	if err != nil {
+		t.Fatalf("unable to parse %q: %s", schedule, err)
+	}
+
+	location, err := LoadTimezone(timezone)
+	if err != nil {
+		t.Fatalf("unable to load %q: %s", timezone, err)
+	}
+
+	zoned := newZonedSchedule(spec, location)
+
+	runs := []time.Time{}
+	next := from
+	for i := 0; i < n; i++ {
+		next = zoned.Next(next)
+		runs = append(runs, next)
+	}
+
+	return runs
+}
+
+func checkRuns(t *testing.T, got []time.Time, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d runs, got %d", len(want), len(got))
+	}
+
+	for i := range want {
+		if got[i].Format(time.RFC3339) != want[i] {
+			t.Errorf("run %d expected: %s, got: %s", i, want[i], got[i].Format(time.RFC3339))
+		}
+	}
+}
+
+func TestZonedSchedule_SpringForward(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	testcases := []struct {
+		Name     string
+		Schedule string
+		From     time.Time
+		Want     []string
+	}{
+		{
+			Name:     "daily run inside the gap is moved to the end of the gap",
+			Schedule: "30 2 * * *",
+			From:     time.Date(2024, 3, 9, 1, 0, 0, 0, newYork),
+			Want: []string{
+				"2024-03-09T02:30:00-05:00",
+				"2024-03-10T03:00:00-04:00",
+				"2024-03-11T02:30:00-04:00",
+			},
+		},
+		{
+			Name:     "runs inside the gap are coalesced",
+			Schedule: "*/30 * * * *",
+			From:     time.Date(2024, 3, 10, 1, 15, 0, 0, newYork),
+			Want: []string{
+				"2024-03-10T01:30:00-05:00",
+				"2024-03-10T03:00:00-04:00",
+				"2024-03-10T03:30:00-04:00",
+			},
+		},
+		{
+			Name:     "runs outside of the gap are unaffected",
+			Schedule: "0 9 * * *",
+			From:     time.Date(2024, 3, 9, 1, 0, 0, 0, newYork),
+			Want: []string{
+				"2024-03-09T09:00:00-05:00",
+				"2024-03-10T09:00:00-04:00",
+				"2024-03-11T09:00:00-04:00",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runs := nextRuns(t, tc.Schedule, "America/New_York", tc.From, len(tc.Want))
+			for i := range runs {
+				runs[i] = runs[i].In(newYork)
+			}
+
+			checkRuns(t, runs, tc.Want)
+		})
+	}
+}
+
+func TestZonedSchedule_FallBack(t *testing.T) {
+	newYork, _ := time.LoadLocation("America/New_York")
+
+	testcases := []struct {
+		Name     string
+		Schedule string
+		From     time.Time
+		Want     []string
+	}{
+		{
+			Name:     "daily run in the repeated hour runs once",
+			Schedule: "30 1 * * *",
+			From:     time.Date(2024, 11, 2, 12, 0, 0, 0, newYork),
+			Want: []string{
+				"2024-11-03T01:30:00-04:00",
+				"2024-11-04T01:30:00-05:00",
+			},
+		},
+		{
+			Name:     "repeated hour is not run twice",
+			Schedule: "*/30 * * * *",
+			From:     time.Date(2024, 11, 3, 0, 45, 0, 0, newYork),
+			Want: []string{
+				"2024-11-03T01:00:00-04:00",
+				"2024-11-03T01:30:00-04:00",
+				"2024-11-03T02:00:00-05:00",
+			},
+		},
+		{
+			Name:     "starting inside the repeated hour waits for the next new slot",
+			Schedule: "*/30 * * * *",
+			From:     time.Date(2024, 11, 3, 6, 10, 0, 0, time.UTC),
+			Want: []string{
+				"2024-11-03T02:00:00-05:00",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runs := nextRuns(t, tc.Schedule, "America/New_York", tc.From, len(tc.Want))
+			for i := range runs {
+				runs[i] = runs[i].In(newYork)
+			}
+
+			checkRuns(t, runs, tc.Want)
+		})
+	}
+}
+
+func TestZonedSchedule_Descriptor(t *testing.T) {
+	spec, err := standardParser.Parse("@every 1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newZonedSchedule(spec, time.UTC) != spec {
+		t.Error("expected @every schedule to be returned unchanged")
+	}
+}
+
+func TestToCronFunctions_Timezone(t *testing.T) {
+	testcases := []struct {
+		Name     string
+		Schedule string
+		Timezone string
+		WantErr  bool
+	}{
+		{Name: "no timezone", Schedule: "0 9 * * *"},
+		{Name: "valid timezone", Schedule: "0 9 * * *", Timezone: "Europe/London"},
+		{Name: "unknown timezone", Schedule: "0 9 * * *", Timezone: "Mars/Olympus_Mons", WantErr: true},
+		{Name: "local is not allowed", Schedule: "0 9 * * *", Timezone: "Local", WantErr: true},
+		{Name: "timezone set twice", Schedule: "CRON_TZ=UTC 0 9 * * *", Timezone: "Europe/London", WantErr: true},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.Name, func(t *testing.T) {
+			annotations := map[string]string{"schedule": tc.Schedule}
+			if len(tc.Timezone) > 0 {
+				annotations["schedule_timezone"] = tc.Timezone
+			}
+
+			cfs := testCronFunctions(t, tc.WantErr, Defaults{}, annotations)
+			if tc.WantErr {
+				return
+			}
+			cf := cfs[0]
+
+			if cf.Timezone != tc.Timezone {
+				t.Errorf("expected: %s, got: %s", tc.Timezone, cf.Timezone)
+			}
+		})
+	}
+}